@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/history"
+	"github.com/LinusMB/Notify/internal/lock"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/LinusMB/Notify/internal/queue"
+)
+
+const commandUsage = `  list
+    	list the notifications that are shown or wait to be shown
+  dismiss <id>
+    	close the notifications with the given -id or pid, or drop them if they wait
+  dismiss-all
+    	close or drop all notifications
+  pause
+    	hold back notifications that wait to be shown
+  resume
+    	show the notifications held back by pause
+  dnd on|off|status
+    	turn do-not-disturb on or off, which shows the deferred notifications, or show its status
+  history [-reopen N] [query]
+    	list the shown notifications, newest first, or those whose text contains query.
+    	-reopen N shows the Nth notification of the list again
+`
+
+// summaryWidth is the maximum number of characters of the text of a
+// notification in the list.
+const summaryWidth = 40
+
+// summary returns the text of the notification on a single line.
+func summary(n *parsing.Notification) string {
+	text := n.Body
+	if n.Title != "" {
+		text = n.Title + ": " + text
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func truncate(s string, n int) string {
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	return string(rs[:n-1]) + "…"
+}
+
+func runCommand(args []string) int {
+	name, args := args[0], args[1:]
+	if name == "history" {
+		return historyCommand(args)
+	}
+	wantArgs := 0
+	if name == "dismiss" || name == "dnd" {
+		wantArgs = 1
+	}
+	if len(args) != wantArgs {
+		failIf(
+			fmt.Errorf("%s takes %d arguments, got %d", name, wantArgs, len(args)),
+			"parse command",
+		)
+	}
+
+	switch name {
+	case "list":
+		entries, err := queue.List(config.lockDir)
+		failIf(err, "list notifications")
+		printEntries(entries, queue.IsPaused(config.lockDir))
+	case "dismiss":
+		entries, err := queue.List(config.lockDir)
+		failIf(err, "list notifications")
+		var matched []queue.Entry
+		for _, e := range entries {
+			if e.ID == args[0] || strconv.Itoa(e.Pid) == args[0] {
+				matched = append(matched, e)
+			}
+		}
+		if len(matched) == 0 {
+			failIf(fmt.Errorf("no notification %q", args[0]), "dismiss notification")
+		}
+		dismiss(matched)
+	case "dismiss-all":
+		entries, err := queue.List(config.lockDir)
+		failIf(err, "list notifications")
+		dismiss(entries)
+	case "pause":
+		failIf(queue.Pause(config.lockDir), "pause notifications")
+	case "resume":
+		failIf(queue.Resume(config.lockDir), "resume notifications")
+	case "dnd":
+		switch args[0] {
+		case "on":
+			failIf(queue.EnableDND(config.lockDir), "turn on do-not-disturb")
+		case "off":
+			failIf(queue.DisableDND(config.lockDir), "turn off do-not-disturb")
+			deferred, err := queue.ListDeferred(config.stateDir)
+			failIf(err, "list deferred notifications")
+			replay(deferred)
+		case "status":
+			deferred, err := queue.ListDeferred(config.stateDir)
+			failIf(err, "list deferred notifications")
+			if queue.DNDEnabled(config.lockDir) {
+				fmt.Printf("on, %d notifications deferred\n", len(deferred))
+			} else {
+				fmt.Println("off")
+			}
+		default:
+			failIf(fmt.Errorf("expected on, off or status, got %q", args[0]), "parse command")
+		}
+	default:
+		failIf(fmt.Errorf("unknown command %q", name), "parse command")
+	}
+	return 0
+}
+
+func printEntries(entries []queue.Entry, paused bool) {
+	if paused {
+		fmt.Println("Notifications are paused.")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tCHANNEL\tID\tSTATUS\tSINCE\tTEXT")
+	for _, e := range entries {
+		fmt.Fprintf(
+			w,
+			"%d\t%s\t%s\t%s\t%s\t%s\n",
+			e.Pid,
+			e.Channel,
+			e.ID,
+			e.Status,
+			e.Since.Format(time.TimeOnly),
+			truncate(e.Text, summaryWidth),
+		)
+	}
+	w.Flush()
+}
+
+// dismiss closes the notifications the same way as -replace does, which
+// also drops notifications that wait for the lock.
+func dismiss(entries []queue.Entry) {
+	for _, e := range entries {
+		p, err := os.FindProcess(e.Pid)
+		if err == nil {
+			err = p.Signal(lock.ReplaceSignal)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not dismiss notification %d: %v\n", e.Pid, err)
+		}
+	}
+}
+
+// replay shows the deferred notifications in the background. They are
+// started in the order they came in, but all at once, so that the ones of
+// the same channel are shown in the order they get the lock. A notification
+// is removed only once it has been started, so that the ones that fail are
+// replayed the next time.
+func replay(deferred []queue.Deferred) {
+	if len(deferred) == 0 {
+		return
+	}
+	exe, err := os.Executable()
+	failIf(err, "replay deferred notifications")
+	for _, d := range deferred {
+		if err := start(exe, d); err != nil {
+			fmt.Fprintf(os.Stderr, "could not replay notification: %v\n", err)
+			continue
+		}
+		if err := d.Remove(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+}
+
+// start runs the deferred notification without waiting for it. Its input
+// is passed in a removed temporary file rather than a pipe, which would
+// need to be written after this process has exited.
+func start(exe string, d queue.Deferred) error {
+	stdin, err := os.CreateTemp("", appName)
+	if err != nil {
+		return err
+	}
+	defer stdin.Close()
+	os.Remove(stdin.Name())
+	if _, err := stdin.WriteString(d.Input); err != nil {
+		return err
+	}
+	if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, d.Args...)
+	cmd.Dir = d.Dir
+	cmd.Stdin = stdin
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+func historyCommand(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	reopen := fs.Int("reopen", 0, "show the Nth notification of the list again")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		failIf(
+			fmt.Errorf("history takes at most 1 query, got %d", fs.NArg()),
+			"parse command",
+		)
+	}
+	query := fs.Arg(0)
+
+	entries, err := history.Read(config.historyDir)
+	failIf(err, "read history")
+	// The list is numbered from the newest notification on, so that the
+	// numbers stay the same when searching.
+	type numbered struct {
+		n int
+		e history.Entry
+	}
+	var matched []numbered
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Matches(query) {
+			matched = append(matched, numbered{len(entries) - i, entries[i]})
+		}
+	}
+
+	if *reopen != 0 {
+		if *reopen < 0 || *reopen > len(entries) {
+			failIf(
+				fmt.Errorf("no notification %d in the history of %d", *reopen, len(entries)),
+				"reopen notification",
+			)
+		}
+		return reopenEntry(entries[len(entries)-*reopen])
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "N\tTIME\tEVENT\tTEXT")
+	for _, m := range matched {
+		fmt.Fprintf(
+			w,
+			"%d\t%s\t%s\t%s\n",
+			m.n,
+			m.e.Time.Format(time.DateTime),
+			m.e.Event,
+			truncate(summary(&parsing.Notification{Title: m.e.Title, Body: m.e.Body}), summaryWidth),
+		)
+	}
+	w.Flush()
+	return 0
+}
+
+// reopenEntry shows the notification again with the arguments it was
+// shown with and returns its exit code.
+func reopenEntry(e history.Entry) int {
+	exe, err := os.Executable()
+	failIf(err, "reopen notification")
+	n := parsing.Notification{Title: e.Title, Body: e.Body}
+	cmd := exec.Command(exe, e.Args...)
+	cmd.Dir = e.Dir
+	cmd.Stdin = strings.NewReader(n.String())
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	failIf(err, "reopen notification")
+	return 0
+}
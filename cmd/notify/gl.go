@@ -0,0 +1,12 @@
+//go:build !nogl
+
+package main
+
+import (
+	ipixel "github.com/LinusMB/Notify/internal/pixel"
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+// glBackend is the OpenGL backend, which links libGL. Building with the
+// nogl tag leaves it out.
+var glBackend render.Backend = ipixel.WindowBackend{}
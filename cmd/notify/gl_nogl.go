@@ -0,0 +1,7 @@
+//go:build nogl
+
+package main
+
+import "github.com/LinusMB/Notify/internal/render"
+
+var glBackend render.Backend
@@ -1,62 +1,119 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"image"
 	"image/color"
 	"io"
 	"log"
 	"math"
 	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
 
 	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/group"
+	"github.com/LinusMB/Notify/internal/history"
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/lock"
 	"github.com/LinusMB/Notify/internal/parsing"
-	ipixel "github.com/LinusMB/Notify/internal/pixel"
-
-	"github.com/faiface/pixel/pixelgl"
-	"golang.org/x/image/font"
-	"golang.org/x/sys/unix"
+	"github.com/LinusMB/Notify/internal/queue"
+	"github.com/LinusMB/Notify/internal/raster"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/LinusMB/Notify/internal/rules"
+	"github.com/LinusMB/Notify/internal/sound"
+	"github.com/LinusMB/Notify/internal/term"
+	"github.com/LinusMB/Notify/internal/theme"
+	"github.com/LinusMB/Notify/internal/wayland"
+	"github.com/LinusMB/Notify/internal/x11"
 )
 
 type Configuration struct {
-	fontFaceRegular font.Face
-	fontFaceBold    font.Face
-	fontSize        float64
-	winWidth        float64
-	winHeight       float64
-	winX            float64
-	winY            float64
-	borderWidth     float64
-	borderColor     color.Color
-	bgColor         color.Color
-	fgColor         color.Color
-	outputString    string
-	duration        time.Duration
+	fonts        *ifont.FontSet
+	fontSize     float64
+	winWidth     float64
+	winHeight    float64
+	winX         float64
+	winY         float64
+	borderWidth  float64
+	borderColor  color.Color
+	bgColor      color.Color
+	bgGradient   *parsing.Gradient
+	bgImage      image.Image
+	bgImageMode  parsing.ImageMode
+	fgColor      color.Color
+	linkColor    color.Color
+	opacity      float64
+	outputString string
+	outputFormat *template.Template
+	outputJSON   bool
+	markdown     bool
+	duration     time.Duration
+	renderPNG    string
+	clickThrough bool
+	sticky       bool
+	backend      render.Backend
+	lockDir      string
+	lockPath     string
+	lockPolicy   lock.Policy
+	channel      string
+	id           string
+	socketPath   string
+	historyDir   string
+	stateDir     string
+	dedupWindow  time.Duration
+	group        string
+	rate         *parsing.Rate
+	ratePath     string
+	urgency      parsing.Urgency
+	command      []string
+	input        []byte
+	suppressed   bool
+	sound        *sound.Sound
+	soundSink    sound.Sink
+	app          string
+	actions      actions
+	opener       []string
+	copy         string
 }
 
 var (
-	config   Configuration
-	appName  = "notify"
-	lockPath = fmt.Sprintf("/tmp/%s.lock", appName)
+	config  Configuration
+	appName = "notify"
 )
 
+// pauseInterval is how often a notification checks whether the paused
+// queue has been resumed.
+const pauseInterval = 250 * time.Millisecond
+
 func failIf(err error, msg string) {
 	if err != nil {
-		log.Fatalf("error %s: %v", msg, err)
+		log.Printf("error %s: %v", msg, err)
+		os.Exit(exitError)
 	}
 }
 
-func init() {
+// parseConfig parses the command line, and stdin unless a command is
+// given, into config.
+func parseConfig() {
 	help := func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [command]\n", os.Args[0])
 		fmt.Fprintf(
 			os.Stderr,
 			"\nDisplays text read from stdin in a pop-up notification window\n",
 		)
+		fmt.Fprintf(os.Stderr, "\nCommands:\n%s", commandUsage)
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExit codes:\n%s", exitCodeUsage)
 	}
 	flag.Usage = help
 
@@ -85,6 +142,11 @@ If -f and -fp are unspecified the default font (Inconsolata) is used.
 If both -f and -fp are specified, -fp is preferred.
 Example: -fp "/usr/share/fonts/TTF/Inconsolata-Regular.ttf,/usr/share/fonts/TTF/Inconsolata-Bold.ttf"`,
 	)
+	opacity := flag.Float64(
+		"opacity",
+		1,
+		`window opacity between 0 (invisible) and 1 (opaque).
+Together with colors that have an alpha channel (e.g. -B "#000000cc") this requires a compositor.`)
 	fontSize := flag.Float64(
 		"s",
 		30,
@@ -93,11 +155,19 @@ Example: -fp "/usr/share/fonts/TTF/Inconsolata-Regular.ttf,/usr/share/fonts/TTF/
 		"e",
 		"",
 		"string that is printed to stdout after notification closes")
+	outputFormat := flag.String(
+		"output-format",
+		"",
+		`template of a line that is printed to stdout when notify exits, or "json" for a JSON object.
+The template has the fields .Reason (e.g. "timeout" or "right-click", see the exit codes), .ExitCode,
+.Action (the command run by -on-click or -on-right-click), .Elapsed, .Title, .Body, .ID, .Channel and .App.
+Example: -output-format "{{.Reason}} {{.Elapsed}}"`)
 	duration := flag.Duration(
 		"d",
 		6*time.Second,
 		`duration after which the notification window closes.
-If -d 0 is given, the notfication window will not close.`)
+If -d 0 is given, the notfication window will not close, which the tmux status line
+used by -backend term without a terminal does not support.`)
 	borderWidth := flag.Float64(
 		"bw",
 		2,
@@ -105,18 +175,243 @@ If -d 0 is given, the notfication window will not close.`)
 	borderColor := flag.String(
 		"bc",
 		"#fff",
-		"border color in hex format #rrggbb or #rgb")
+		`border color as #rgb, #rgba, #rrggbb, #rrggbbaa, color name (e.g. "SteelBlue"),
+rgb()/rgba()/hsl()/hsla() notation or Xlib rgb:rr/gg/bb notation`)
 	backgroundColor := flag.String(
 		"B",
 		"#000",
-		"background color in hex format #rrggbb or #rgb")
+		`background color as #rgb, #rgba, #rrggbb, #rrggbbaa, color name (e.g. "SteelBlue"),
+rgb()/rgba()/hsl()/hsla() notation or Xlib rgb:rr/gg/bb notation.
+A gradient can be given as "linear-gradient([<angle>deg,] <color> [<offset>%], ...)"
+or "radial-gradient(<color> [<offset>%], ...)".
+Example: -B "linear-gradient(90deg, #1d2021, SteelBlue 80%)"`)
+	backgroundImage := flag.String(
+		"bi",
+		"",
+		"path to a png, jpeg or gif image that is drawn over the background")
+	backgroundImageMode := flag.String(
+		"bm",
+		"cover",
+		`how the background image fills the window: "cover", "stretch" or "tile"`)
 	foregroundColor := flag.String(
 		"F",
 		"#fff",
-		"foreground color in hex format #rrggbb or #rgb")
+		`foreground color as #rgb, #rgba, #rrggbb, #rrggbbaa, color name (e.g. "SteelBlue"),
+rgb()/rgba()/hsl()/hsla() notation or Xlib rgb:rr/gg/bb notation`)
+	linkColor := flag.String(
+		"lc",
+		"",
+		`color of URLs in the body like -F. If -lc is unspecified, URLs have the foreground color.`)
+	opener := flag.String(
+		"opener",
+		"xdg-open",
+		`command that opens URLs in the body when they are clicked.
+If -opener "" is given, clicks on URLs close the notification like other clicks.`)
+	copyText := flag.String(
+		"copy",
+		"body",
+		`text that a middle click copies to the clipboard: "body", "all" for the title and body or "" for none.
+The notification flashes when its text is copied. The text can be pasted while the notification is shown,
+or later if a clipboard manager is running.`)
+	markdown := flag.Bool(
+		"markdown",
+		false,
+		`format the body as Markdown: bullet lists ("- item"), inline code in backticks and code blocks
+fenced by three backticks or tildes. Code is shown in a monospace font.`)
+	renderPNG := flag.String(
+		"render-png",
+		"",
+		`render the notification into the png file at the given path instead of opening a window.
+No display is required in this mode and the notification does not wait for other notifications to close.`)
+	clickThrough := flag.Bool(
+		"click-through",
+		false,
+		`let clicks pass through the notification to the windows below, e.g. for volume or brightness popups.
+The notification then only closes after the duration given by -d, which must not be 0.`)
+	sticky := flag.Bool(
+		"sticky",
+		false,
+		"show the notification on all workspaces")
+	channel := flag.String(
+		"channel",
+		lock.DefaultChannel,
+		`lock channel of the notification. Notifications of the same channel are shown one after another,
+notifications of different channels at the same time.`)
+	lockDir := flag.String(
+		"lock-dir",
+		lock.Dir(appName),
+		"directory of the lock files of the channels")
+	stateDir := flag.String(
+		"state-dir",
+		queue.StateDir(appName),
+		"directory of the notifications deferred by do-not-disturb, which unlike the lock files are kept across reboots")
+	noWait := flag.Bool(
+		"no-wait",
+		false,
+		fmt.Sprintf(`drop the notification and exit with code %d if a notification of the same channel is shown`, exitLocked))
+	replace := flag.Bool(
+		"replace",
+		false,
+		"close the notification of the same channel that is shown instead of waiting for it")
+	id := flag.String(
+		"id",
+		"",
+		`id of the notification, e.g. "volume". If a notification with the same id is shown,
+its text is replaced and its duration restarts instead of waiting for it to close.`)
+	dedup := flag.Duration(
+		"dedup",
+		0,
+		`collapse identical notifications of the same channel that are sent within the given duration
+of each other into one notification with a counter, e.g. -dedup 10s`)
+	groupKey := flag.String(
+		"group",
+		"",
+		fmt.Sprintf(`group of the notification. Notifications of the same group and channel that queue up
+are folded into a single summary, which shows the notifications when clicked.
+If -group %s is given, notifications are grouped by the prefix of their title up to the first colon,
+e.g. "CI" for "CI: build 12 passed", or by the whole title if it has no colon.`, group.TitleKey))
+	rate := flag.String(
+		"rate",
+		"",
+		fmt.Sprintf(`maximum rate of notifications of the same channel as "<count>/<period>", e.g. -rate 5/1m.
+Notifications over the rate are dropped and exit with code %d.`, exitLimited))
+	historyDir := flag.String(
+		"history-dir",
+		history.Dir(appName),
+		`directory of the history of shown notifications. If -history-dir "" is given, no history is kept.`)
+	urgency := flag.String(
+		"u",
+		"normal",
+		fmt.Sprintf(`urgency of the notification: "low", "normal" or "critical".
+While do-not-disturb is on, critical notifications are shown, normal notifications are deferred
+until it is turned off and low notifications are dropped. Both exit with code %d.`, exitSuppressed))
+	soundPath := flag.String(
+		"sound",
+		"",
+		`path to a wav or ogg file that is played when the notification is shown.
+It is played with the first of pacat, pw-cat, aplay and ffplay that is installed and does not fail.`)
+	onClick := flag.String(
+		"on-click",
+		"",
+		`shell command that is run when the notification is clicked, e.g. -on-click "xdg-open https://example.com".
+It runs detached from notify, with the text of the notification in $NOTIFY_TITLE and $NOTIFY_BODY
+and its options in $NOTIFY_APP, $NOTIFY_CHANNEL, $NOTIFY_ID and $NOTIFY_URGENCY.`)
+	onRightClick := flag.String(
+		"on-right-click",
+		"",
+		"shell command that is run when the notification is right-clicked, like -on-click")
+	actionFlags := make(actions)
+	flag.Var(
+		actionFlags,
+		"on",
+		`shell command that is run when the notification closes with an event, like -on-click, e.g.
+-on "timeout=notify-send missed". Events are left-click, right-click, middle-click,
+timeout, closed, dismissed and canceled.
+It can be given once per event.`)
+	app := flag.String(
+		"app",
+		"",
+		"name of the application that sends the notification, which rules can match on")
+	configPath := flag.String(
+		"config",
+		rules.Path(appName),
+		`configuration file with rules that style or suppress notifications matching them.
+Options given via command-line arguments take precedence over the rules.`)
+	backend := flag.String(
+		"backend",
+		"auto",
+		`backend that displays the notification: "auto", "gl", "x11", "wayland" or "term".
+"gl" opens an OpenGL window, "x11" a software rendered X11 window for displays without OpenGL,
+"wayland" a wlr-layer-shell surface that can be positioned on Wayland compositors like sway
+and "term" draws the notification in the terminal.
+"auto" uses "gl" (or "x11" if notify is built with -tags nogl) if DISPLAY or WAYLAND_DISPLAY is set
+and "term" otherwise.`)
+	xresources := flag.String(
+		"xr",
+		"",
+		fmt.Sprintf(`Xresources file to read colors and font from.
+If -xr %s is given, the resources are queried with xrdb -query. Files with #define or #include
+are run through cpp like xrdb does.
+The resources notify.background, notify.foreground, notify.borderColor and notify.font are read,
+falling back to *.background, *.foreground and *.color1 respectively.
+Colors and font given via command-line arguments take precedence.`, theme.XrdbSource))
+	themePath := flag.String(
+		"th",
+		"",
+		`pywal colors.json or base16 JSON scheme to read colors from.
+-th takes precedence over -xr; colors given via command-line arguments take precedence over both.`)
 
 	flag.Parse()
 
+	config.lockDir = *lockDir
+	config.historyDir = *historyDir
+	config.stateDir = *stateDir
+	if flag.NArg() > 0 {
+		config.command = flag.Args()
+		return
+	}
+
+	config.input = readInput()
+
+	{
+		u, err := parsing.ParseUrgency(*urgency)
+		failIf(err, "parse urgency")
+		config.urgency = u
+	}
+
+	{
+		rs, err := rules.Load(*configPath)
+		failIf(err, "load rules")
+		n := parsing.ParseNotification(string(config.input))
+		settings, suppress := rules.Match(rs, rules.Message{
+			Title:   n.Title,
+			Body:    n.Body,
+			App:     *app,
+			Urgency: config.urgency,
+		})
+		config.suppressed = suppress
+
+		isSet := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { isSet[f.Name] = true })
+		for setting, value := range settings {
+			name := ruleFlags[setting]
+			if isSet[name] || (name == "f" && isSet["fp"]) {
+				continue
+			}
+			failIf(flag.Set(name, value), "apply rule setting "+setting)
+		}
+	}
+
+	{
+		isSet := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { isSet[f.Name] = true })
+
+		var th parsing.Theme
+		if *xresources != "" {
+			t, err := theme.LoadXresources(*xresources, appName)
+			failIf(err, "load Xresources")
+			th.Merge(t)
+		}
+		if *themePath != "" {
+			t, err := theme.LoadJSON(*themePath)
+			failIf(err, "load theme")
+			th.Merge(t)
+		}
+
+		if th.BorderColor != "" && !isSet["bc"] {
+			*borderColor = th.BorderColor
+		}
+		if th.Background != "" && !isSet["B"] {
+			*backgroundColor = th.Background
+		}
+		if th.Foreground != "" && !isSet["F"] {
+			*foregroundColor = th.Foreground
+		}
+		if th.Font != "" && !isSet["f"] && !isSet["fp"] {
+			*fontFamily = th.Font
+		}
+	}
+
 	{
 		dim, err := parsing.ParseDimension(*dimension)
 		failIf(err, "parse dimension")
@@ -152,121 +447,648 @@ If -d 0 is given, the notfication window will not close.`)
 			fs, err = ifont.LoadOpentypeFontSetDefault(*fontSize)
 		}
 		failIf(err, "load font")
-		config.fontFaceRegular = fs.Regular
-		config.fontFaceBold = fs.Bold
+		config.fonts = fs
 	}
 	{
 		c, err := parsing.ParseColor(*borderColor)
 		failIf(err, "parse border color")
-		config.borderColor = c
+		config.borderColor = color.NRGBA(c)
 	}
-	{
+	if parsing.IsGradient(*backgroundColor) {
+		g, err := parsing.ParseGradient(*backgroundColor)
+		failIf(err, "parse background gradient")
+		config.bgGradient = g
+	} else {
 		c, err := parsing.ParseColor(*backgroundColor)
 		failIf(err, "parse background color")
-		config.bgColor = c
+		config.bgColor = color.NRGBA(c)
+	}
+	if *backgroundImage != "" {
+		img, err := layout.LoadImage(*backgroundImage)
+		failIf(err, "load background image")
+		config.bgImage = img
+		mode, err := parsing.ParseImageMode(*backgroundImageMode)
+		failIf(err, "parse background image mode")
+		config.bgImageMode = mode
 	}
 	{
 		c, err := parsing.ParseColor(*foregroundColor)
 		failIf(err, "parse foreground color")
-		config.fgColor = c
+		config.fgColor = color.NRGBA(c)
+	}
+	if *linkColor != "" {
+		c, err := parsing.ParseColor(*linkColor)
+		failIf(err, "parse link color")
+		config.linkColor = color.NRGBA(c)
+	}
+
+	if *opacity < 0 || *opacity > 1 {
+		failIf(
+			fmt.Errorf("opacity %v is not between 0 and 1", *opacity),
+			"parse opacity",
+		)
+	}
+
+	if *outputFormat == "json" {
+		config.outputJSON = true
+	} else if *outputFormat != "" {
+		t, err := template.New("output").Parse(*outputFormat)
+		failIf(err, "parse output format")
+		config.outputFormat = t
+	}
+
+	switch *copyText {
+	case "", "body", "all":
+	default:
+		failIf(fmt.Errorf("unknown text %q", *copyText), "parse copy")
+	}
+
+	if *clickThrough && *duration == 0 {
+		failIf(
+			fmt.Errorf("-click-through requires a duration, but -d is 0"),
+			"parse duration",
+		)
+	}
+
+	{
+		path, err := lock.Path(*lockDir, *channel)
+		failIf(err, "parse channel")
+		config.lockPath = path
+
+		switch {
+		case *noWait && *replace:
+			failIf(
+				fmt.Errorf("-no-wait and -replace are mutually exclusive"),
+				"parse lock policy",
+			)
+		case *noWait:
+			config.lockPolicy = lock.NoWait
+		case *replace:
+			config.lockPolicy = lock.Replace
+		default:
+			config.lockPolicy = lock.Wait
+		}
+
+		if *id != "" {
+			path, err := lock.SocketPath(*lockDir, *id)
+			failIf(err, "parse id")
+			config.socketPath = path
+		}
+		config.channel = *channel
+		config.id = *id
+
+		if *rate != "" {
+			r, err := parsing.ParseRate(*rate)
+			failIf(err, "parse rate")
+			config.rate = r
+			config.ratePath, err = lock.RatePath(*lockDir, *channel)
+			failIf(err, "parse channel")
+		}
 	}
 
+	config.opacity = *opacity
 	config.borderWidth = *borderWidth
 	config.fontSize = *fontSize
 	config.duration = *duration
 	config.outputString = *outputString
+	config.renderPNG = *renderPNG
+	config.clickThrough = *clickThrough
+	config.markdown = *markdown
+	config.sticky = *sticky
+	config.dedupWindow = *dedup
+	config.group = *groupKey
+	config.app = *app
+	config.actions = actionFlags
+	if *onClick != "" {
+		config.actions[render.EventLeftClick] = *onClick
+	}
+	if *onRightClick != "" {
+		config.actions[render.EventRightClick] = *onRightClick
+	}
+	config.opener = strings.Fields(*opener)
+	config.copy = *copyText
+	if config.renderPNG == "" {
+		config.backend = selectBackend(*backend)
+	}
+	if *soundPath != "" {
+		s, err := sound.Load(*soundPath)
+		failIf(err, "load sound")
+		config.sound = s
+		config.soundSink = sound.DefaultSink()
+	}
 }
 
-func run() {
-	var notification *parsing.Notification
-	{
-		bytes, err := io.ReadAll(os.Stdin)
-		failIf(err, "read from stdin")
-		input := string(bytes)
-		notification = parsing.ParseNotification(input)
-	}
-
-	notifText := ipixel.SetupNotificationText(
-		config.fontFaceRegular,
-		config.fontFaceBold,
-		config.fgColor,
-		notification.Title,
-		notification.Body,
-	)
+// ruleFlags maps the settings of rules to the flags they set.
+var ruleFlags = map[string]string{
+	"background":   "B",
+	"foreground":   "F",
+	"link-color":   "lc",
+	"border-color": "bc",
+	"border-width": "bw",
+	"font":         "f",
+	"font-size":    "s",
+	"geometry":     "g",
+	"duration":     "d",
+	"opacity":      "opacity",
+	"channel":      "channel",
+	"backend":      "backend",
+	"sound":        "sound",
+	"markdown":     "markdown",
+}
 
-	var winWidth, winHeight float64
-	if config.winWidth == 0 || config.winHeight == 0 {
-		padding := math.Round(config.fontSize / 2)
-		winWidth = notifText.W() + 2*padding + 2*config.borderWidth
-		winHeight = notifText.H() + 2*padding + 2*config.borderWidth
-	} else {
-		winWidth = config.winWidth
-		winHeight = config.winHeight
+func selectBackend(name string) render.Backend {
+	if name == "auto" {
+		name = "gl"
+		if glBackend == nil {
+			name = "x11"
+		}
+		if !term.HasDisplay() {
+			name = "term"
+		}
 	}
+	switch name {
+	case "gl":
+		if glBackend == nil {
+			failIf(
+				errors.New(`notify was built without OpenGL (-tags nogl), use "x11" or "wayland"`),
+				"select backend",
+			)
+		}
+		return glBackend
+	case "x11":
+		return x11.Backend{}
+	case "wayland":
+		return wayland.Backend{}
+	case "term":
+		b, err := term.Fallback()
+		failIf(err, "select backend")
+		return b
+	}
+	failIf(fmt.Errorf("unknown backend %q", name), "select backend")
+	return nil
+}
 
-	notifWin := ipixel.SetupNotificationWindow(
-		winWidth,
-		winHeight,
-		config.borderWidth,
-		config.bgColor,
-		config.borderColor,
-	)
+func readInput() []byte {
+	input, err := io.ReadAll(os.Stdin)
+	failIf(err, "read from stdin")
+	return input
+}
 
-	win, err := ipixel.SetupWindow(
-		appName,
-		winWidth,
-		winHeight,
-		config.winX,
-		config.winY,
+func setupLayout(notification *parsing.Notification) *layout.Layout {
+	return layout.New(
+		layout.Config{
+			Fonts:       config.fonts,
+			Width:       config.winWidth,
+			Height:      config.winHeight,
+			Padding:     math.Round(config.fontSize / 2),
+			BorderWidth: config.borderWidth,
+			BorderColor: config.borderColor,
+			Background: layout.Background{
+				Color:     config.bgColor,
+				Gradient:  config.bgGradient,
+				Image:     config.bgImage,
+				ImageMode: config.bgImageMode,
+			},
+			Foreground: config.fgColor,
+			LinkColor:  config.linkColor,
+			Opacity:    config.opacity,
+			Markdown:   config.markdown,
+		},
+		notification,
 	)
-	failIf(err, "setup window")
+}
 
-	notifWin.Draw(win)
-	notifText.Draw(win)
+// run shows the notification and returns how it was closed together with
+// the notification that was shown last, as updates may have replaced it.
+func run(
+	backend render.Backend,
+	notification *parsing.Notification,
+	cancel <-chan struct{},
+	updates <-chan *parsing.Notification,
+) (render.Event, *parsing.Notification) {
+	l := setupLayout(notification)
+	var openLink func(string)
+	if len(config.opener) > 0 {
+		openLink = func(url string) {
+			cmd := exec.Command(config.opener[0], append(config.opener[1:], url)...)
+			if err := detach(cmd); err != nil {
+				log.Printf("warning: could not open %s: %v", url, err)
+			}
+		}
+	}
+	var copyText func() string
+	if config.copy != "" {
+		copyText = func() string {
+			switch {
+			case notification.Body == "":
+				return notification.Title
+			case config.copy == "all" && notification.Title != "":
+				return notification.Title + "\n" + notification.Body
+			}
+			return notification.Body
+		}
+	}
+	ev, err := backend.Show(l, render.Options{
+		Title:        appName,
+		X:            config.winX,
+		Y:            config.winY,
+		Duration:     config.duration,
+		ClickThrough: config.clickThrough,
+		Sticky:       config.sticky,
+		Cancel:       cancel,
+		Updates:      updates,
+		Relayout: func(n *parsing.Notification) *layout.Layout {
+			notification = n
+			return setupLayout(n)
+		},
+		OpenLink: openLink,
+		Copy:     copyText,
+	})
+	failIf(err, "show notification")
+	return ev, notification
+}
 
-	var (
-		exitCode int
-		closeWin <-chan time.Time
+// actions maps events to the shell commands that are run for them. It is
+// set with -on EVENT=COMMAND.
+type actions map[render.Event]string
+
+func (a actions) String() string {
+	var pairs []string
+	for ev, command := range a {
+		pairs = append(pairs, ev.String()+"="+command)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+func (a actions) Set(s string) error {
+	name, command, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(command) == "" {
+		return fmt.Errorf("could not parse action %s: expected EVENT=COMMAND", s)
+	}
+	ev, err := render.ParseEvent(name)
+	if err != nil {
+		return err
+	}
+	a[ev] = command
+	return nil
+}
+
+// runAction starts the command for ev, if any, without waiting for it, and
+// returns it.
+func runAction(ev render.Event, n *parsing.Notification) string {
+	command := config.actions[ev]
+	if command == "" {
+		return ""
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Env = append(
+		os.Environ(),
+		"NOTIFY_TITLE="+n.Title,
+		"NOTIFY_BODY="+n.Body,
+		"NOTIFY_APP="+config.app,
+		"NOTIFY_CHANNEL="+config.channel,
+		"NOTIFY_ID="+config.id,
+		"NOTIFY_URGENCY="+config.urgency.String(),
 	)
+	if err := detach(cmd); err != nil {
+		log.Printf("warning: could not run %q: %v", command, err)
+		return ""
+	}
+	return command
+}
 
-	if config.duration == 0 {
-		closeWin = time.After(time.Duration(math.MaxInt64))
-	} else {
-		closeWin = time.After(config.duration)
+// detach starts cmd without waiting for it. The command keeps running
+// after notify exited, without holding on to its output, which callers may
+// be reading until notify exits.
+func detach(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
 	}
+	return cmd.Process.Release()
+}
 
-Loop:
-	for !win.Closed() {
-		if win.JustPressed(pixelgl.MouseButtonLeft) {
-			exitCode = 0
-			break Loop
-		}
-		if win.JustPressed(pixelgl.MouseButtonRight) {
-			exitCode = 1
-			break Loop
-		}
+// playSound starts playing the sound of the notification, if any, and
+// returns a function that stops it.
+func playSound() (stop func()) {
+	if config.sound == nil {
+		return func() {}
+	}
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := config.soundSink.Play(config.sound, stopped)
 		select {
-		case <-closeWin:
-			break Loop
+		case <-stopped:
 		default:
-			win.Update()
+			if err != nil {
+				log.Printf("warning: sound is not played: %v", err)
+			}
 		}
+	}()
+	return func() {
+		close(stopped)
+		<-done
 	}
+}
 
-	if config.outputString != "" {
-		fmt.Fprint(os.Stdout, config.outputString)
+func recordHistory(n *parsing.Notification, r result) {
+	if config.historyDir == "" {
+		return
+	}
+	wd, _ := os.Getwd()
+	if err := history.Append(config.historyDir, history.Entry{
+		Time:     time.Now(),
+		Title:    n.Title,
+		Body:     n.Body,
+		Event:    r.Reason,
+		ExitCode: r.ExitCode,
+		Action:   r.Action,
+		Args:     os.Args[1:],
+		Dir:      wd,
+	}); err != nil {
+		log.Printf("warning: notification is not recorded in the history: %v", err)
 	}
-	os.Exit(exitCode)
 }
 
 func main() {
-	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0666)
-	failIf(err, "open lock file")
-	defer lockFile.Close()
+	parseConfig()
+	if config.command != nil {
+		os.Exit(runCommand(config.command))
+	}
 
-	if err := unix.Flock(int(lockFile.Fd()), unix.LOCK_EX); err != nil {
-		failIf(err, "acquire lock")
+	input := config.input
+	if config.suppressed {
+		exitWith(newResult(reasonSuppressed, exitSuppressed, parsing.ParseNotification(string(input))))
+	}
+
+	if config.renderPNG != "" {
+		ev, n := run(
+			raster.ImageBackend{Path: config.renderPNG},
+			parsing.ParseNotification(string(input)),
+			nil,
+			nil,
+		)
+		r := eventResult(ev, n)
+		finish(r)
+		os.Exit(r.ExitCode)
+	}
+
+	if config.urgency != parsing.UrgencyCritical && queue.DNDEnabled(config.lockDir) {
+		if config.urgency == parsing.UrgencyNormal {
+			wd, err := os.Getwd()
+			failIf(err, "defer notification")
+			failIf(queue.Defer(config.stateDir, queue.Deferred{
+				Args:  os.Args[1:],
+				Dir:   wd,
+				Input: string(input),
+			}), "defer notification")
+		}
+		exitWith(newResult(reasonSuppressed, exitSuppressed, parsing.ParseNotification(string(input))))
+	}
+
+	notification := parsing.ParseNotification(string(input))
+	var dedupPath string
+	if config.dedupWindow > 0 {
+		path, err := lock.DedupPath(config.lockDir, config.channel, notification.String())
+		failIf(err, "parse channel")
+		dedupPath = path
+	}
+	var groupPath string
+	key := group.Key(config.group, notification)
+	if key != "" {
+		path, err := lock.GroupPath(config.lockDir, config.channel, key)
+		failIf(err, "parse channel")
+		groupPath = path
+	}
+	for _, path := range []string{config.socketPath, dedupPath, groupPath} {
+		if path == "" {
+			continue
+		}
+		if err := lock.Handover(path, input); err == nil {
+			exitWith(newResult(reasonMerged, exitMerged, notification))
+		}
+	}
+
+	if config.rate != nil {
+		ok, err := lock.Allow(
+			config.ratePath,
+			config.rate.Count,
+			config.rate.Period,
+			time.Now(),
+		)
+		if err != nil {
+			log.Printf("warning: rate of notifications is not limited: %v", err)
+		} else if !ok {
+			exitWith(newResult(reasonLimited, exitLimited, notification))
+		}
+	}
+
+	// Notifications with the same id, content or group are taken over from
+	// now on, also while waiting for the lock.
+	updates := make(chan *parsing.Notification)
+	var stops []func()
+	stopListening := func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+	if config.socketPath != "" {
+		stop, err := listen(config.socketPath, updates, func(n *parsing.Notification) (*parsing.Notification, func()) {
+			return n, nil
+		})
+		if err != nil {
+			log.Printf("warning: notifications with the same id are not replaced: %v", err)
+		} else {
+			stops = append(stops, stop)
+		}
+	}
+	var g *group.Group
+	if groupPath != "" {
+		g = group.New(key, notification)
+	}
+	if dedupPath != "" {
+		// Duplicates are taken over until none was sent within the window.
+		// Once the group shows a summary, they are added to it instead of
+		// being counted, which would replace the summary.
+		count := 1
+		original := notification
+		expired := time.NewTimer(config.dedupWindow)
+		stop, err := listen(dedupPath, updates, func(n *parsing.Notification) (*parsing.Notification, func()) {
+			if g != nil && g.Folded() {
+				return g.With(n), func() { g.Add(n) }
+			}
+			return counted(original, count+1), func() {
+				count++
+				expired.Reset(config.dedupWindow)
+			}
+		})
+		if err != nil {
+			log.Printf("warning: identical notifications are not collapsed: %v", err)
+		} else {
+			stops = append(stops, stop)
+			go func() {
+				<-expired.C
+				stop()
+			}()
+		}
+	}
+	if groupPath != "" {
+		stop, err := listen(groupPath, updates, func(n *parsing.Notification) (*parsing.Notification, func()) {
+			return g.With(n), func() { g.Add(n) }
+		})
+		if err != nil {
+			log.Printf("warning: notifications of the group are not folded: %v", err)
+		} else {
+			stops = append(stops, stop)
+		}
+	}
+
+	// The signal must be handled before the lock is acquired and the
+	// notification is listed, see lock.ReplaceSignal.
+	replaced := make(chan os.Signal, 1)
+	signal.Notify(replaced, lock.ReplaceSignal)
+	cancel := make(chan struct{})
+	go func() {
+		<-replaced
+		close(cancel)
+	}()
+
+	reg, err := queue.Register(config.lockDir, queue.Entry{
+		Pid:     os.Getpid(),
+		Channel: config.channel,
+		ID:      config.id,
+		Text:    summary(notification),
+		Status:  queue.Waiting,
+	})
+	if err != nil {
+		log.Printf("warning: notification is not listed: %v", err)
+	}
+	setStatus := func(s queue.Status) {
+		if reg != nil {
+			reg.SetStatus(s)
+		}
+	}
+
+	var (
+		l         *lock.Lock
+		stopSound func()
+	)
+	exit := func(code int) {
+		stopListening()
+		if reg != nil {
+			reg.Remove()
+		}
+		if l != nil {
+			l.Release()
+		}
+		// The player would keep running after the process exits.
+		if stopSound != nil {
+			stopSound()
+		}
+		os.Exit(code)
+	}
+
+	type acquired struct {
+		l   *lock.Lock
+		err error
+	}
+	locked := make(chan acquired, 1)
+	go func() {
+		l, err := lock.Acquire(config.lockPath, config.lockPolicy)
+		locked <- acquired{l, err}
+	}()
+	// The notification waits for the lock of its channel and then for the
+	// queue to be resumed, taking over updates meanwhile.
+	var resumed <-chan time.Time
+	for l == nil || resumed != nil {
+		select {
+		case n := <-updates:
+			notification = n
+			if reg != nil {
+				reg.SetText(summary(n))
+			}
+		case a := <-locked:
+			if errors.Is(a.err, lock.ErrLocked) {
+				writeResult(os.Stdout, newResult(reasonLocked, exitLocked, notification))
+				exit(exitLocked)
+			}
+			failIf(a.err, "acquire lock")
+			l = a.l
+		case <-cancel:
+			writeResult(os.Stdout, eventResult(render.EventCanceled, notification))
+			exit(exitCanceled)
+		case <-resumed:
+		}
+		resumed = nil
+		if l != nil && queue.IsPaused(config.lockDir) {
+			setStatus(queue.Paused)
+			resumed = time.After(pauseInterval)
+		}
+	}
+	setStatus(queue.Shown)
+	stopSound = playSound()
+
+	start := time.Now()
+	ev, shown := run(config.backend, notification, cancel, updates)
+	// Clicking the summary of a group shows its messages.
+	if ev == render.EventLeftClick && g != nil && g.Expand() {
+		ev, shown = run(config.backend, g.Notification(), cancel, updates)
+	}
+	r := eventResult(ev, shown)
+	r.Action = runAction(ev, shown)
+	r.Elapsed = elapsed(time.Since(start))
+	finish(r)
+	recordHistory(shown, r)
+	exit(r.ExitCode)
+}
+
+// listen takes over the notifications that are handed over to the socket
+// at path until stop is called. take is called with each of them, one at a
+// time, and returns the notification that is sent on updates and a function
+// that is called once it has been sent, if it is not nil. Handovers that
+// are refused because listening stopped meanwhile thus leave no trace.
+func listen(
+	path string,
+	updates chan<- *parsing.Notification,
+	take func(n *parsing.Notification) (update *parsing.Notification, taken func()),
+) (stop func(), err error) {
+	stopped := make(chan struct{})
+	ln, err := lock.Listen(path, func(input []byte) bool {
+		update, taken := take(parsing.ParseNotification(string(input)))
+		select {
+		case updates <- update:
+			if taken != nil {
+				taken()
+			}
+			return true
+		case <-stopped:
+			return false
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopped)
+			ln.Close()
+		})
+	}, nil
+}
+
+// counted returns the notification with the number of times it was sent.
+func counted(n *parsing.Notification, count int) *parsing.Notification {
+	c := *n
+	suffix := fmt.Sprintf(" (×%d)", count)
+	if c.Title != "" {
+		c.Title += suffix
+	} else {
+		c.Body += suffix
 	}
-	defer unix.Flock(int(lockFile.Fd()), unix.LOCK_UN)
-	pixelgl.Run(run)
+	return &c
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+// Exit codes by the reason a notification ended. Left and right clicks keep
+// the codes 0 and 1 they had before the other reasons were told apart.
+// Errors exit with 2 like invalid options do in the flag package, so that
+// they are not mistaken for a right click.
+const (
+	exitLeftClick   = 0
+	exitRightClick  = 1
+	exitError       = 2
+	exitLocked      = 3
+	exitSuppressed  = 4
+	exitLimited     = 5
+	exitTimeout     = 6
+	exitClosed      = 7
+	exitMiddleClick = 8
+	exitDismissed   = 9
+	exitCanceled    = 10
+	exitMerged      = 11
+)
+
+// Reasons of notifications that are not shown, besides the events of
+// notifications that are.
+const (
+	reasonLocked     = "locked"
+	reasonSuppressed = "suppressed"
+	reasonLimited    = "limited"
+	reasonMerged     = "merged"
+	reasonRendered   = "rendered"
+)
+
+// exitCodeUsage documents the exit codes in the help.
+var exitCodeUsage = fmt.Sprintf(`  %2d  left click (or the notification was rendered with -render-png)
+  %2d  right click
+  %2d  error, e.g. an invalid option
+  %2d  locked: dropped because of -no-wait
+  %2d  suppressed: deferred or dropped by do-not-disturb or a rule
+  %2d  limited: dropped because of -rate
+  %2d  timeout
+  %2d  closed by the window manager or compositor
+  %2d  middle click (with -copy "")
+  %2d  dismissed with the keyboard, which only the terminal backend supports
+  %2d  canceled by -replace or notify dismiss
+  %2d  merged: handed over to a shown notification because of -id, -dedup or -group
+`,
+	exitLeftClick,
+	exitRightClick,
+	exitError,
+	exitLocked,
+	exitSuppressed,
+	exitLimited,
+	exitTimeout,
+	exitClosed,
+	exitMiddleClick,
+	exitDismissed,
+	exitCanceled,
+	exitMerged,
+)
+
+// A result describes why a notification ended, for its exit code and the
+// output of -output-format.
+type result struct {
+	Reason   string  `json:"reason"`
+	ExitCode int     `json:"exit_code"`
+	Action   string  `json:"action"`
+	Elapsed  elapsed `json:"elapsed"`
+	Title    string  `json:"title"`
+	Body     string  `json:"body"`
+	ID       string  `json:"id"`
+	Channel  string  `json:"channel"`
+	App      string  `json:"app"`
+}
+
+// elapsed is how long a notification was shown. It is formatted like a
+// time.Duration rounded to milliseconds and encoded in JSON as seconds.
+type elapsed time.Duration
+
+func (e elapsed) String() string {
+	return time.Duration(e).Round(time.Millisecond).String()
+}
+
+func (e elapsed) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(e).Seconds())
+}
+
+func newResult(reason string, exitCode int, n *parsing.Notification) result {
+	return result{
+		Reason:   reason,
+		ExitCode: exitCode,
+		Title:    n.Title,
+		Body:     n.Body,
+		ID:       config.id,
+		Channel:  config.channel,
+		App:      config.app,
+	}
+}
+
+// eventResult returns the result of a notification that was closed by ev.
+func eventResult(ev render.Event, n *parsing.Notification) result {
+	var exitCode int
+	switch ev {
+	case render.EventNone:
+		// Only -render-png does not show the notification interactively.
+		return newResult(reasonRendered, exitLeftClick, n)
+	case render.EventLeftClick:
+		exitCode = exitLeftClick
+	case render.EventRightClick:
+		exitCode = exitRightClick
+	case render.EventTimeout:
+		exitCode = exitTimeout
+	case render.EventClosed:
+		exitCode = exitClosed
+	case render.EventMiddleClick:
+		exitCode = exitMiddleClick
+	case render.EventDismissed:
+		exitCode = exitDismissed
+	case render.EventCanceled:
+		exitCode = exitCanceled
+	}
+	return newResult(ev.String(), exitCode, n)
+}
+
+// writeResult prints r to w in the format given with -output-format.
+func writeResult(w io.Writer, r result) {
+	var err error
+	switch {
+	case config.outputJSON:
+		err = json.NewEncoder(w).Encode(r)
+	case config.outputFormat != nil:
+		if err = config.outputFormat.Execute(w, r); err == nil {
+			fmt.Fprintln(w)
+		}
+	}
+	if err != nil {
+		log.Printf("warning: could not print result: %v", err)
+	}
+}
+
+// exitWith prints r and exits with its code, for notifications that are
+// not shown.
+func exitWith(r result) {
+	writeResult(os.Stdout, r)
+	os.Exit(r.ExitCode)
+}
+
+// finish prints the output string and the result of a notification that
+// was shown.
+func finish(r result) {
+	if config.outputString != "" {
+		fmt.Fprint(os.Stdout, config.outputString)
+	}
+	writeResult(os.Stdout, r)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+func TestEventResult(t *testing.T) {
+	tests := []struct {
+		ev         render.Event
+		wantReason string
+		wantCode   int
+	}{
+		{render.EventNone, "rendered", 0},
+		{render.EventLeftClick, "left-click", 0},
+		{render.EventRightClick, "right-click", 1},
+		{render.EventTimeout, "timeout", 6},
+		{render.EventClosed, "closed", 7},
+		{render.EventMiddleClick, "middle-click", 8},
+		{render.EventDismissed, "dismissed", 9},
+		{render.EventCanceled, "canceled", 10},
+	}
+	n := &parsing.Notification{Title: "Backup", Body: "Done."}
+	for _, tt := range tests {
+		r := eventResult(tt.ev, n)
+		if r.Reason != tt.wantReason || r.ExitCode != tt.wantCode {
+			t.Errorf("eventResult(%v) = %q with code %d, want %q with code %d",
+				tt.ev, r.Reason, r.ExitCode, tt.wantReason, tt.wantCode)
+		}
+		if r.Title != n.Title || r.Body != n.Body {
+			t.Errorf("eventResult(%v) has text %q %q, want %q %q", tt.ev, r.Title, r.Body, n.Title, n.Body)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	// The codes are part of the interface of notify and must not change.
+	codes := []int{
+		exitLeftClick,
+		exitRightClick,
+		exitError,
+		exitLocked,
+		exitSuppressed,
+		exitLimited,
+		exitTimeout,
+		exitClosed,
+		exitMiddleClick,
+		exitDismissed,
+		exitCanceled,
+		exitMerged,
+	}
+	for want, code := range codes {
+		if code != want {
+			t.Errorf("exit code %d is listed at %d", code, want)
+		}
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	r := result{
+		Reason:   "timeout",
+		ExitCode: exitTimeout,
+		Elapsed:  elapsed(3001400 * time.Microsecond),
+		Title:    "Backup",
+		Body:     "Done.",
+		Channel:  "default",
+	}
+	tests := []struct {
+		name   string
+		json   bool
+		format string
+		want   string
+	}{
+		{"none", false, "", ""},
+		{"template", false, "{{.Reason}} after {{.Elapsed}} ({{.ExitCode}})", "timeout after 3.001s (6)\n"},
+		{"failing template", false, "{{.Reason}}{{.Missing}}", "timeout"},
+		{
+			"json",
+			true,
+			"",
+			`{"reason":"timeout","exit_code":6,"action":"","elapsed":3.0014,"title":"Backup","body":"Done.","id":"","channel":"default","app":""}` + "\n",
+		},
+	}
+	defer func(c Configuration) { config = c }(config)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.outputJSON = tt.json
+			config.outputFormat = nil
+			if tt.format != "" {
+				config.outputFormat = template.Must(template.New("output").Parse(tt.format))
+			}
+			var b bytes.Buffer
+			writeResult(&b, r)
+			if got := b.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
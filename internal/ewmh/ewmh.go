@@ -0,0 +1,206 @@
+package ewmh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/shape"
+	"github.com/jezek/xgb/xproto"
+)
+
+func InternAtom(c *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(c, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("could not intern atom %s: %w", name, err)
+	}
+	return reply.Atom, nil
+}
+
+func changeProperty(
+	c *xgb.Conn,
+	win xproto.Window,
+	property string,
+	typ xproto.Atom,
+	format byte,
+	data []byte,
+) error {
+	atom, err := InternAtom(c, property)
+	if err != nil {
+		return err
+	}
+	if err := xproto.ChangePropertyChecked(
+		c,
+		xproto.PropModeReplace,
+		win,
+		atom,
+		typ,
+		format,
+		uint32(len(data)*8/int(format)),
+		data,
+	).Check(); err != nil {
+		return fmt.Errorf("could not set %s: %w", property, err)
+	}
+	return nil
+}
+
+// changeCardinalProperty sets a property of format 32, whose data is sent in
+// the byte order of the client, which xgb always declares as little-endian.
+func changeCardinalProperty(
+	c *xgb.Conn,
+	win xproto.Window,
+	property string,
+	typ xproto.Atom,
+	values ...uint32,
+) error {
+	data := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v)
+	}
+	return changeProperty(c, win, property, typ, 32, data)
+}
+
+func changeAtomProperty(
+	c *xgb.Conn,
+	win xproto.Window,
+	property string,
+	values ...string,
+) error {
+	atoms := make([]uint32, len(values))
+	for i, v := range values {
+		atom, err := InternAtom(c, v)
+		if err != nil {
+			return err
+		}
+		atoms[i] = uint32(atom)
+	}
+	return changeCardinalProperty(c, win, property, xproto.AtomAtom, atoms...)
+}
+
+// Class returns the class part of WM_CLASS for the instance name, which
+// by convention is the capitalized instance name, e.g. "Notify".
+func Class(instance string) string {
+	if instance == "" {
+		return ""
+	}
+	return strings.ToUpper(instance[:1]) + instance[1:]
+}
+
+// ICCCM WM_HINTS flags and states.
+const (
+	hintInput   = 1
+	hintState   = 2
+	normalState = 1
+)
+
+// allDesktops is the _NET_WM_DESKTOP of windows shown on all desktops.
+const allDesktops = 0xffffffff
+
+// SetNotificationHints names the window and marks it as a notification
+// that is kept above other windows, is not shown in taskbars and pagers and
+// does not take the input focus. A sticky window is shown on all desktops.
+// As window managers read the hints when the window is mapped, they must be
+// set before.
+func SetNotificationHints(
+	c *xgb.Conn,
+	win xproto.Window,
+	title string,
+	sticky bool,
+) error {
+	utf8String, err := InternAtom(c, "UTF8_STRING")
+	if err != nil {
+		return err
+	}
+	if err := changeProperty(
+		c, win, "WM_NAME", xproto.AtomString, 8, []byte(title),
+	); err != nil {
+		return err
+	}
+	if err := changeProperty(
+		c, win, "_NET_WM_NAME", utf8String, 8, []byte(title),
+	); err != nil {
+		return err
+	}
+	// WM_CLASS holds the instance and class name, each null-terminated.
+	class := title + "\x00" + Class(title) + "\x00"
+	if err := changeProperty(
+		c, win, "WM_CLASS", xproto.AtomString, 8, []byte(class),
+	); err != nil {
+		return err
+	}
+	if err := changeAtomProperty(
+		c,
+		win,
+		"_NET_WM_WINDOW_TYPE",
+		"_NET_WM_WINDOW_TYPE_NOTIFICATION",
+	); err != nil {
+		return err
+	}
+	states := []string{
+		"_NET_WM_STATE_SKIP_TASKBAR",
+		"_NET_WM_STATE_SKIP_PAGER",
+		"_NET_WM_STATE_ABOVE",
+	}
+	if sticky {
+		states = append(states, "_NET_WM_STATE_STICKY")
+		if err := changeCardinalProperty(
+			c, win, "_NET_WM_DESKTOP", xproto.AtomCardinal, allDesktops,
+		); err != nil {
+			return err
+		}
+	}
+	if err := changeAtomProperty(c, win, "_NET_WM_STATE", states...); err != nil {
+		return err
+	}
+	// With the input field of WM_HINTS unset the window manager does not
+	// focus the window, and a user time of 0 asks it not to focus the
+	// window when it is mapped.
+	if err := changeCardinalProperty(
+		c,
+		win,
+		"WM_HINTS",
+		xproto.AtomWmHints,
+		hintInput|hintState, 0, normalState, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		return err
+	}
+	return changeCardinalProperty(
+		c, win, "_NET_WM_USER_TIME", xproto.AtomCardinal, 0,
+	)
+}
+
+// SetClickThrough makes the window ignore pointer input by setting its
+// input shape to the empty region, so that clicks reach the windows below.
+func SetClickThrough(c *xgb.Conn, win xproto.Window) error {
+	if err := shape.Init(c); err != nil {
+		return fmt.Errorf("could not initialize shape extension: %w", err)
+	}
+	if err := shape.RectanglesChecked(
+		c,
+		shape.SoSet,
+		shape.SkInput,
+		xproto.ClipOrderingUnsorted,
+		win,
+		0,
+		0,
+		nil,
+	).Check(); err != nil {
+		return fmt.Errorf("could not set input shape: %w", err)
+	}
+	return nil
+}
+
+// Compositing reports whether a compositing manager is running on the
+// screen, see the _NET_WM_CM_Sn selection of the EWMH specification.
+func Compositing(c *xgb.Conn) (bool, error) {
+	atom, err := InternAtom(c, fmt.Sprintf("_NET_WM_CM_S%d", c.DefaultScreen))
+	if err != nil {
+		return false, err
+	}
+	reply, err := xproto.GetSelectionOwner(c, atom).Reply()
+	if err != nil {
+		return false, fmt.Errorf("could not get compositing manager: %w", err)
+	}
+	return reply.Owner != xproto.WindowNone, nil
+}
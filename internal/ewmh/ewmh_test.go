@@ -0,0 +1,112 @@
+package ewmh
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/shape"
+	"github.com/jezek/xgb/xproto"
+)
+
+func TestClass(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"notify", "Notify"},
+		{"Notify", "Notify"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Class(tt.input); got != tt.want {
+			t.Errorf("Class(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestSetNotificationHints needs an X server, e.g. run it with
+// xvfb-run go test ./internal/ewmh
+func TestSetNotificationHints(t *testing.T) {
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set")
+	}
+	c, err := xgb.NewConn()
+	if err != nil {
+		t.Skipf("could not connect to X server: %v", err)
+	}
+	defer c.Close()
+
+	screen := xproto.Setup(c).DefaultScreen(c)
+	win, err := xproto.NewWindowId(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xproto.CreateWindowChecked(
+		c, 0, win, screen.Root, 0, 0, 1, 1, 0,
+		xproto.WindowClassInputOutput, 0, 0, nil,
+	).Check(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetNotificationHints(c, win, "notify", true); err != nil {
+		t.Fatal(err)
+	}
+
+	property := func(name string) []byte {
+		atom, err := InternAtom(c, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply, err := xproto.GetProperty(
+			c, false, win, atom, xproto.GetPropertyTypeAny, 0, 64,
+		).Reply()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.Value
+	}
+	atoms := func(names ...string) []byte {
+		var b []byte
+		for _, name := range names {
+			atom, err := InternAtom(c, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b = append(b, byte(atom), byte(atom>>8), byte(atom>>16), byte(atom>>24))
+		}
+		return b
+	}
+
+	if got := property("WM_CLASS"); string(got) != "notify\x00Notify\x00" {
+		t.Errorf("got WM_CLASS %q", got)
+	}
+	want := atoms("_NET_WM_WINDOW_TYPE_NOTIFICATION")
+	if got := property("_NET_WM_WINDOW_TYPE"); !bytes.Equal(got, want) {
+		t.Errorf("got _NET_WM_WINDOW_TYPE % x, want % x", got, want)
+	}
+	want = atoms(
+		"_NET_WM_STATE_SKIP_TASKBAR",
+		"_NET_WM_STATE_SKIP_PAGER",
+		"_NET_WM_STATE_ABOVE",
+		"_NET_WM_STATE_STICKY",
+	)
+	if got := property("_NET_WM_STATE"); !bytes.Equal(got, want) {
+		t.Errorf("got _NET_WM_STATE % x, want % x", got, want)
+	}
+	want = []byte{0xff, 0xff, 0xff, 0xff}
+	if got := property("_NET_WM_DESKTOP"); !bytes.Equal(got, want) {
+		t.Errorf("got _NET_WM_DESKTOP % x, want % x", got, want)
+	}
+
+	if err := SetClickThrough(c, win); err != nil {
+		t.Fatal(err)
+	}
+	rects, err := shape.GetRectangles(c, win, shape.SkInput).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rects.Rectangles) != 0 {
+		t.Errorf("got input shape %v, want empty", rects.Rectangles)
+	}
+}
@@ -122,12 +122,16 @@ func LoadOpentypeFontFromFamily(
 type FontSet struct {
 	Regular font.Face
 	Bold    font.Face
+	// Mono is the face of code, which is the embedded monospace font unless
+	// the regular font is the embedded one too.
+	Mono font.Face
 }
 
-func newFontSet(regular font.Face, bold font.Face) *FontSet {
+func newFontSet(regular, bold, mono font.Face) *FontSet {
 	fs := FontSet{
 		Regular: regular,
 		Bold:    bold,
+		Mono:    mono,
 	}
 	return &fs
 }
@@ -144,7 +148,11 @@ func LoadOpentypeFontSetFromFamily(
 	if err != nil {
 		return nil, err
 	}
-	return newFontSet(regular, bold), nil
+	mono, err := loadOpentypeFontFromBytes(inconsolataRegular, size)
+	if err != nil {
+		return nil, err
+	}
+	return newFontSet(regular, bold, mono), nil
 }
 
 func LoadOpentypeFontSetFromPaths(
@@ -159,7 +167,11 @@ func LoadOpentypeFontSetFromPaths(
 	if err != nil {
 		return nil, err
 	}
-	return newFontSet(regular, bold), nil
+	mono, err := loadOpentypeFontFromBytes(inconsolataRegular, size)
+	if err != nil {
+		return nil, err
+	}
+	return newFontSet(regular, bold, mono), nil
 }
 
 func LoadOpentypeFontSetDefault(size float64) (*FontSet, error) {
@@ -171,5 +183,5 @@ func LoadOpentypeFontSetDefault(size float64) (*FontSet, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newFontSet(regular, bold), nil
+	return newFontSet(regular, bold, regular), nil
 }
@@ -0,0 +1,120 @@
+package group
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+)
+
+// TitleKey groups notifications by the prefix of their title up to the
+// first colon, e.g. "CI" for "CI: build 12", or by the whole title if it
+// has none.
+const TitleKey = "title"
+
+// Key returns the group of the notification for the key given on the
+// command line, which is empty if the notification is not grouped.
+func Key(key string, n *parsing.Notification) string {
+	if key == TitleKey {
+		prefix, _, _ := strings.Cut(n.Title, ":")
+		return strings.TrimSpace(prefix)
+	}
+	return key
+}
+
+// A Group folds the notifications of a group that queue up into a single
+// notification. It is safe for concurrent use.
+type Group struct {
+	mu       sync.Mutex
+	key      string
+	messages []*parsing.Notification
+	expanded bool
+}
+
+func New(key string, n *parsing.Notification) *Group {
+	return &Group{key: key, messages: []*parsing.Notification{n}}
+}
+
+// Add adds a notification to the group.
+func (g *Group) Add(n *parsing.Notification) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.messages = append(g.messages, n)
+}
+
+// Folded reports whether the group shows more than one message.
+func (g *Group) Folded() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.messages) > 1
+}
+
+// With returns the notification that would show the group if n was added,
+// without adding it.
+func (g *Group) With(n *parsing.Notification) *parsing.Notification {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	with := Group{
+		key:      g.key,
+		messages: append(g.messages[:len(g.messages):len(g.messages)], n),
+		expanded: g.expanded,
+	}
+	return with.notification()
+}
+
+// Expand lists the messages of the group instead of summarizing them. It
+// reports false if there is nothing to expand.
+func (g *Group) Expand() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.expanded || len(g.messages) == 1 {
+		return false
+	}
+	g.expanded = true
+	return true
+}
+
+// Notification returns the notification that shows the group: its only
+// message, a summary of its messages or the list of them once expanded.
+func (g *Group) Notification() *parsing.Notification {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.notification()
+}
+
+func (g *Group) notification() *parsing.Notification {
+	if len(g.messages) == 1 {
+		return g.messages[0]
+	}
+	if !g.expanded {
+		return &parsing.Notification{
+			Title: g.key,
+			Body:  fmt.Sprintf("%d new messages", len(g.messages)),
+		}
+	}
+	lines := make([]string, len(g.messages))
+	for i, m := range g.messages {
+		text := m.Body
+		if title := g.subtitle(m); title != "" {
+			text = title + ": " + text
+		}
+		lines[i] = "- " + strings.Join(strings.Fields(text), " ")
+	}
+	return &parsing.Notification{
+		Title: g.key,
+		Body:  strings.Join(lines, "\n"),
+	}
+}
+
+// subtitle returns the title of m without the key of the group, which the
+// summary already shows.
+func (g *Group) subtitle(m *parsing.Notification) string {
+	if m.Title == g.key {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(m.Title, g.key+":"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return m.Title
+}
@@ -0,0 +1,82 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		title string
+		want  string
+	}{
+		{"", "CI", ""},
+		{"builds", "CI", "builds"},
+		{TitleKey, "CI", "CI"},
+		{TitleKey, "CI: build 12", "CI"},
+		{TitleKey, " CI :a:b", "CI"},
+		{TitleKey, "", ""},
+	}
+	for _, tt := range tests {
+		n := &parsing.Notification{Title: tt.title, Body: "build 12 passed"}
+		if got := Key(tt.key, n); got != tt.want {
+			t.Errorf("Key(%q) of %q = %q, want %q", tt.key, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	first := &parsing.Notification{Title: "CI", Body: "build 12 passed"}
+	g := New("CI", first)
+	if got := g.Notification(); got != first {
+		t.Errorf("got %v for a single message, want the message", got)
+	}
+	if g.Expand() {
+		t.Error("a single message was expanded")
+	}
+	if g.Folded() {
+		t.Error("a single message was folded")
+	}
+
+	second := &parsing.Notification{Title: "CI: build 13", Body: "failed\nagain"}
+	want := parsing.Notification{Title: "CI", Body: "2 new messages"}
+	if got := g.With(second); *got != want {
+		t.Errorf("got %v with a second message, want %v", *got, want)
+	}
+	if got := g.Notification(); got != first {
+		t.Errorf("got %v, want the message that was not added to", got)
+	}
+	g.Add(second)
+	if got := g.Notification(); *got != want {
+		t.Errorf("got %v after Add, want %v", *got, want)
+	}
+	if !g.Folded() {
+		t.Error("two messages were not folded")
+	}
+	g.Add(&parsing.Notification{Title: "Deploy", Body: "staging is up"})
+	want = parsing.Notification{Title: "CI", Body: "3 new messages"}
+	if got := g.Notification(); *got != want {
+		t.Errorf("got %v, want %v", *got, want)
+	}
+
+	if !g.Expand() {
+		t.Fatal("summary was not expanded")
+	}
+	if g.Expand() {
+		t.Error("expanded group was expanded again")
+	}
+	want = parsing.Notification{
+		Title: "CI",
+		Body:  "- build 12 passed\n- build 13: failed again\n- Deploy: staging is up",
+	}
+	if got := g.Notification(); *got != want {
+		t.Errorf("got expanded %q, want %q", got.Body, want.Body)
+	}
+	g.Add(&parsing.Notification{Body: "build 14 passed"})
+	want.Body += "\n- build 14 passed"
+	if got := g.Notification(); *got != want {
+		t.Errorf("got expanded %q after Add, want %q", got.Body, want.Body)
+	}
+}
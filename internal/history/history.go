@@ -0,0 +1,128 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxSize is the size in bytes after which the history file is rotated.
+// Only the rotated file of the last rotation is kept.
+const maxSize = 1 << 20
+
+const (
+	fileName    = "history.jsonl"
+	rotatedName = "history.1.jsonl"
+	lockName    = "history.lock"
+)
+
+// An Entry records a notification that was shown and the command that was
+// run when it was closed, if any. It is shown again by running the program
+// with Args in Dir and the notification on stdin.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Event    string    `json:"event"`
+	ExitCode int       `json:"exit_code"`
+	Action   string    `json:"action,omitempty"`
+	Args     []string  `json:"args"`
+	Dir      string    `json:"dir"`
+}
+
+// Matches reports whether the title or body contain query, ignoring case.
+func (e *Entry) Matches(query string) bool {
+	query = strings.ToLower(query)
+	return strings.Contains(strings.ToLower(e.Title), query) ||
+		strings.Contains(strings.ToLower(e.Body), query)
+}
+
+// Dir returns the default directory of the history file.
+func Dir(app string) string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, app)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", app)
+}
+
+// Append adds the entry to the history file in dir.
+func Append(dir string, e Entry) error {
+	return appendEntry(dir, e, maxSize)
+}
+
+func appendEntry(dir string, e Entry, maxSize int64) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("could not create history directory: %w", err)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	// The lock keeps concurrent notifications from rotating the file twice.
+	lock, err := os.OpenFile(filepath.Join(dir, lockName), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open history lock: %w", err)
+	}
+	defer lock.Close()
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		return fmt.Errorf("could not lock history: %w", err)
+	}
+
+	path := filepath.Join(dir, fileName)
+	if fi, err := os.Stat(path); err == nil && fi.Size()+int64(len(b)) > maxSize {
+		if err := os.Rename(path, filepath.Join(dir, rotatedName)); err != nil {
+			return fmt.Errorf("could not rotate history: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open history: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(b); err != nil {
+		return fmt.Errorf("could not write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries of the history in dir, oldest first. Lines that
+// cannot be parsed, e.g. because they were cut off, are skipped.
+func Read(dir string) ([]Entry, error) {
+	var entries []Entry
+	for _, name := range []string{rotatedName, fileName} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not open history: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, maxSize)
+		for scanner.Scan() {
+			var e Entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read history: %w", err)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "notify")
+	entries, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("got %d entries in new history, want 0", len(entries))
+	}
+
+	want := []Entry{
+		{Title: "Curl", Body: "Download succeeded.", Event: "left-click", Action: "xdg-open ."},
+		{Body: "Volume 60%", Event: "timeout", Args: []string{"-d", "1s"}},
+		{Title: "Build", Body: "failed", Event: "right-click", ExitCode: 1},
+	}
+	for i := range want {
+		want[i].Time = time.Date(2026, 1, 1, 12, i, 0, 0, time.UTC)
+		if err := Append(dir, want[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAppend_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	// Each entry is about 130 bytes, so that every third entry rotates.
+	const maxSize = 300
+	var bodies []string
+	for i := 0; i < 7; i++ {
+		body := string(rune('a' + i))
+		bodies = append(bodies, body)
+		if err := appendEntry(dir, Entry{Body: body}, maxSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(dir, fileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > maxSize {
+		t.Errorf("history file has %d bytes, want at most %d", fi.Size(), maxSize)
+	}
+
+	entries, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || len(entries) >= len(bodies) {
+		t.Fatalf("got %d entries after rotation, want fewer than %d", len(entries), len(bodies))
+	}
+	// The newest entries survive in order.
+	kept := bodies[len(bodies)-len(entries):]
+	for i, e := range entries {
+		if e.Body != kept[i] {
+			t.Errorf("entry %d: got body %q, want %q", i, e.Body, kept[i])
+		}
+	}
+}
+
+func TestEntry_Matches(t *testing.T) {
+	e := Entry{Title: "Curl", Body: "Download succeeded."}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"curl", true},
+		{"SUCCEEDED", true},
+		{"failed", false},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := e.Matches(tt.query); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/home/user/.state")
+	if got := Dir("notify"); got != "/home/user/.state/notify" {
+		t.Errorf("got %q, want /home/user/.state/notify", got)
+	}
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/user")
+	if got := Dir("notify"); got != "/home/user/.local/state/notify" {
+		t.Errorf("got %q, want /home/user/.local/state/notify", got)
+	}
+}
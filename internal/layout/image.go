@@ -0,0 +1,76 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/faiface/pixel"
+)
+
+// LoadImage loads the image at path. Empty images are rejected, since they
+// cannot be scaled or tiled.
+func LoadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open image at path %s: %w", path, err)
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode image %s: %w", path, err)
+	}
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("image %s is empty", path)
+	}
+	return img, nil
+}
+
+func placeImage(
+	src image.Rectangle,
+	mode parsing.ImageMode,
+	r pixel.Rect,
+) []ImagePlacement {
+	w, h := float64(src.Dx()), float64(src.Dy())
+	switch mode {
+	case parsing.ImageStretch:
+		return []ImagePlacement{{src, r}}
+	case parsing.ImageTile:
+		var placements []ImagePlacement
+		for top := r.Max.Y; top > r.Min.Y; top -= h {
+			th := math.Min(h, top-r.Min.Y)
+			for left := r.Min.X; left < r.Max.X; left += w {
+				tw := math.Min(w, r.Max.X-left)
+				placements = append(placements, ImagePlacement{
+					Src: image.Rect(
+						src.Min.X,
+						src.Min.Y,
+						src.Min.X+int(math.Ceil(tw)),
+						src.Min.Y+int(math.Ceil(th)),
+					),
+					Dst: pixel.R(left, top-th, left+tw, top),
+				})
+			}
+		}
+		return placements
+	default:
+		scale := math.Max(r.W()/w, r.H()/h)
+		cw, ch := r.W()/scale, r.H()/scale
+		x0 := float64(src.Min.X) + (w-cw)/2
+		y0 := float64(src.Min.Y) + (h-ch)/2
+		return []ImagePlacement{{
+			Src: image.Rect(
+				int(math.Floor(x0)),
+				int(math.Floor(y0)),
+				int(math.Ceil(x0+cw)),
+				int(math.Ceil(y0+ch)),
+			),
+			Dst: r,
+		}}
+	}
+}
@@ -0,0 +1,392 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+)
+
+// A Layout describes a notification independently of how it is displayed.
+// Coordinates are in pixels with the origin in the bottom left corner of
+// the window and the y-axis pointing upwards.
+type Layout struct {
+	Width  float64
+	Height float64
+
+	// Border holds the strips of the window border.
+	Border  []Box
+	Content Box
+
+	Image      image.Image
+	Placements []ImagePlacement
+
+	// Blocks holds the backgrounds and borders of code, which are drawn
+	// over the content and under the text.
+	Blocks []Box
+	Text   []TextRun
+
+	Opacity float64
+}
+
+type Box struct {
+	Rect     pixel.Rect
+	Color    color.Color
+	Gradient *parsing.Gradient
+}
+
+// ColorAt returns the color of the box at v, which is interpolated if the
+// box is filled with a gradient.
+func (b *Box) ColorAt(v pixel.Vec) color.Color {
+	if b.Gradient == nil {
+		return b.Color
+	}
+	var t float64
+	center := b.Rect.Center()
+	switch b.Gradient.Kind {
+	case parsing.LinearGradient:
+		angle := b.Gradient.Angle * math.Pi / 180
+		dir := pixel.V(math.Sin(angle), math.Cos(angle))
+		length := math.Abs(b.Rect.W()*dir.X) + math.Abs(b.Rect.H()*dir.Y)
+		t = v.Sub(center).Dot(dir)/length + 0.5
+	case parsing.RadialGradient:
+		radius := pixel.V(b.Rect.W(), b.Rect.H()).Scaled(math.Sqrt2 / 2)
+		d := v.Sub(center)
+		t = math.Hypot(d.X/radius.X, d.Y/radius.Y)
+	}
+	return color.NRGBA(b.Gradient.At(t))
+}
+
+// ImagePlacement maps the region Src of the background image, given in
+// image coordinates, to the region Dst of the window.
+type ImagePlacement struct {
+	Src image.Rectangle
+	Dst pixel.Rect
+}
+
+// A TextRun is text in a single face drawn with its baseline origin at Dot.
+// A line is a single run unless it is Markdown with bullets or code, whose
+// runs have the same Line.
+type TextRun struct {
+	Text   string
+	Face   font.Face
+	Bold   bool
+	Color  color.Color
+	Dot    pixel.Vec
+	Bounds pixel.Rect
+	Links  []Link
+	Line   int
+}
+
+// A Link is a URL in a text run, which is drawn in its own color and
+// underlined. Start and End index the runes of the run.
+type Link struct {
+	URL       string
+	Start     int
+	End       int
+	Color     color.Color
+	Bounds    pixel.Rect
+	Underline pixel.Rect
+}
+
+func (tr *TextRun) LineHeight() float64 {
+	return lineHeight(tr.Face)
+}
+
+// GlyphBounds returns the bounds of every rune of the run.
+func (tr *TextRun) GlyphBounds() []pixel.Rect {
+	glyphs, _, _ := layoutLine(tr.Face, tr.Text, tr.Dot, pixel.Rect{})
+	return glyphs
+}
+
+type Background struct {
+	Color     color.Color
+	Gradient  *parsing.Gradient
+	Image     image.Image
+	ImageMode parsing.ImageMode
+}
+
+type Config struct {
+	Fonts *ifont.FontSet
+	// Width and Height of the window. If either is 0, the window is sized
+	// to fit the text.
+	Width       float64
+	Height      float64
+	Padding     float64
+	BorderWidth float64
+	BorderColor color.Color
+	Background  Background
+	Foreground  color.Color
+	// LinkColor is the color of URLs in the body. If it is nil, they have
+	// the foreground color.
+	LinkColor color.Color
+	Opacity   float64
+	// Markdown lays out the body as Markdown, see parsing.ParseMarkdown.
+	Markdown bool
+}
+
+func New(cfg Config, notification *parsing.Notification) *Layout {
+	var (
+		runs     []TextRun
+		blocks   []Box
+		textBox  pixel.Rect
+		titleBox pixel.Rect
+		bodyBox  pixel.Rect
+		dot      pixel.Vec
+		row      int
+	)
+	linkColor := cfg.LinkColor
+	if linkColor == nil {
+		linkColor = cfg.Foreground
+	}
+	addLines := func(
+		text string,
+		face font.Face,
+		bold bool,
+		links bool,
+		acc pixel.Rect,
+	) pixel.Rect {
+		for _, line := range strings.Split(text, "\n") {
+			var glyphs []pixel.Rect
+			glyphs, acc, _ = layoutLine(face, line, dot, acc)
+			run := TextRun{
+				Text:   line,
+				Face:   face,
+				Bold:   bold,
+				Color:  cfg.Foreground,
+				Dot:    dot,
+				Bounds: unionGlyphs(glyphs),
+				Line:   row,
+			}
+			if links {
+				run.Links = layoutLinks(line, glyphs, face, dot, linkColor)
+			}
+			runs = append(runs, run)
+			dot.Y -= lineHeight(face)
+			row++
+		}
+		return acc
+	}
+	if notification.Title != "" {
+		titleBox = addLines(notification.Title, cfg.Fonts.Bold, true, false, titleBox)
+	}
+	if notification.Body != "" && cfg.Markdown {
+		ml := markdownLayout{
+			fonts:     cfg.Fonts,
+			fg:        cfg.Foreground,
+			linkColor: linkColor,
+			dot:       dot,
+			row:       row,
+		}
+		ml.add(parsing.ParseMarkdown(notification.Body))
+		runs = append(runs, ml.runs...)
+		blocks = ml.blocks
+		bodyBox = ml.acc
+	} else if notification.Body != "" {
+		bodyBox = addLines(notification.Body, cfg.Fonts.Regular, false, true, bodyBox)
+	}
+	textBox = titleBox.Union(bodyBox)
+
+	l := Layout{
+		Width:   cfg.Width,
+		Height:  cfg.Height,
+		Opacity: cfg.Opacity,
+	}
+	if l.Width == 0 || l.Height == 0 {
+		l.Width = textBox.W() + 2*cfg.Padding + 2*cfg.BorderWidth
+		l.Height = textBox.H() + 2*cfg.Padding + 2*cfg.BorderWidth
+	}
+
+	center := pixel.V(l.Width/2, l.Height/2)
+	offset := textBox.Center().To(center)
+	for i := range runs {
+		runs[i].Dot = runs[i].Dot.Add(offset)
+		runs[i].Bounds = runs[i].Bounds.Moved(offset)
+		for j := range runs[i].Links {
+			link := &runs[i].Links[j]
+			link.Bounds = link.Bounds.Moved(offset)
+			link.Underline = link.Underline.Moved(offset)
+		}
+	}
+	l.Text = runs
+	for i := range blocks {
+		blocks[i].Rect = blocks[i].Rect.Moved(offset)
+	}
+	l.Blocks = blocks
+
+	window := pixel.R(0, 0, l.Width, l.Height)
+	content := pixel.R(
+		cfg.BorderWidth,
+		cfg.BorderWidth,
+		l.Width-cfg.BorderWidth,
+		l.Height-cfg.BorderWidth,
+	)
+	l.Border = frame(window, content, cfg.BorderColor)
+	l.Content = Box{
+		Rect:     content,
+		Color:    cfg.Background.Color,
+		Gradient: cfg.Background.Gradient,
+	}
+	if cfg.Background.Image != nil {
+		l.Image = cfg.Background.Image
+		l.Placements = placeImage(
+			cfg.Background.Image.Bounds(),
+			cfg.Background.ImageMode,
+			content,
+		)
+	}
+	return &l
+}
+
+// layoutLinks returns the links in line, whose runes have the bounds glyphs.
+// The underline is placed below the baseline at dot.
+func layoutLinks(
+	line string,
+	glyphs []pixel.Rect,
+	face font.Face,
+	dot pixel.Vec,
+	c color.Color,
+) []Link {
+	var links []Link
+	thickness := math.Max(1, math.Round(lineHeight(face)/16))
+	for _, pl := range parsing.FindLinks(line) {
+		start := utf8.RuneCountInString(line[:pl.Start])
+		end := start + utf8.RuneCountInString(line[pl.Start:pl.End])
+		bounds := unionGlyphs(glyphs[start:end])
+		links = append(links, Link{
+			URL:    pl.URL,
+			Start:  start,
+			End:    end,
+			Color:  c,
+			Bounds: bounds,
+			Underline: pixel.R(
+				bounds.Min.X,
+				dot.Y-2*thickness,
+				bounds.Max.X,
+				dot.Y-thickness,
+			),
+		})
+	}
+	return links
+}
+
+// LinkAt returns the URL of the link at v, if there is one.
+func (l *Layout) LinkAt(v pixel.Vec) (string, bool) {
+	for _, run := range l.Text {
+		for _, link := range run.Links {
+			if link.Bounds.Contains(v) {
+				return link.URL, true
+			}
+		}
+	}
+	return "", false
+}
+
+func unionGlyphs(glyphs []pixel.Rect) pixel.Rect {
+	var u pixel.Rect
+	for _, g := range glyphs {
+		if g.W()*g.H() == 0 {
+			continue
+		}
+		u = extendBounds(u, g)
+	}
+	return u
+}
+
+// frame returns the strips between outer and inner, so that translucent
+// content drawn into inner is not blended with the frame color.
+func frame(outer, inner pixel.Rect, c color.Color) []Box {
+	var boxes []Box
+	for _, r := range []pixel.Rect{
+		pixel.R(outer.Min.X, inner.Max.Y, outer.Max.X, outer.Max.Y),
+		pixel.R(outer.Min.X, outer.Min.Y, outer.Max.X, inner.Min.Y),
+		pixel.R(outer.Min.X, inner.Min.Y, inner.Min.X, inner.Max.Y),
+		pixel.R(inner.Max.X, inner.Min.Y, outer.Max.X, inner.Max.Y),
+	} {
+		if r.W() > 0 && r.H() > 0 {
+			boxes = append(boxes, Box{Rect: r, Color: c})
+		}
+	}
+	return boxes
+}
+
+// IsTranslucent reports whether the window background lets the desktop
+// shine through.
+func (l *Layout) IsTranslucent() bool {
+	translucent := func(c color.Color) bool {
+		if c == nil {
+			return false
+		}
+		_, _, _, a := c.RGBA()
+		return a < 0xffff
+	}
+	for _, b := range l.Border {
+		if translucent(b.Color) {
+			return true
+		}
+	}
+	return l.Opacity < 1 ||
+		translucent(l.Content.Color) ||
+		(l.Content.Gradient != nil && l.Content.Gradient.IsTranslucent())
+}
+
+// Opaque returns a copy of the layout with a fully opaque window
+// background, for displays that cannot show translucent windows.
+func (l *Layout) Opaque() *Layout {
+	opaque := func(c color.Color) color.Color {
+		if c == nil {
+			return nil
+		}
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+		nc.A = 0xff
+		return nc
+	}
+	ol := *l
+	ol.Opacity = 1
+	ol.Border = make([]Box, len(l.Border))
+	for i, b := range l.Border {
+		b.Color = opaque(b.Color)
+		ol.Border[i] = b
+	}
+	ol.Content.Color = opaque(l.Content.Color)
+	if l.Content.Gradient != nil {
+		ol.Content.Gradient = l.Content.Gradient.Opaque()
+	}
+	return &ol
+}
+
+// Inverted returns a copy of the layout with the colors of the text and the
+// background swapped, which flashes the notification as feedback.
+func (l *Layout) Inverted() *Layout {
+	if len(l.Text) == 0 {
+		return l
+	}
+	fg := l.Text[0].Color
+	bg := l.Content.ColorAt(l.Content.Rect.Center())
+
+	il := *l
+	il.Content = Box{Rect: l.Content.Rect, Color: fg}
+	il.Image = nil
+	il.Placements = nil
+	il.Blocks = make([]Box, len(l.Blocks))
+	for i, b := range l.Blocks {
+		b.Color = withAlpha(bg, color.NRGBAModel.Convert(b.Color).(color.NRGBA).A)
+		il.Blocks[i] = b
+	}
+	il.Text = make([]TextRun, len(l.Text))
+	for i, run := range l.Text {
+		run.Color = bg
+		run.Links = append([]Link(nil), run.Links...)
+		for j := range run.Links {
+			run.Links[j].Color = bg
+		}
+		il.Text[i] = run
+	}
+	return &il
+}
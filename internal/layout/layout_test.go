@@ -0,0 +1,373 @@
+package layout
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/faiface/pixel"
+)
+
+var (
+	white       = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	black       = color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}
+	translucent = color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0x80}
+)
+
+func testConfig(t *testing.T) Config {
+	t.Helper()
+	fs, err := ifont.LoadOpentypeFontSetDefault(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Config{
+		Fonts:       fs,
+		Padding:     10,
+		BorderWidth: 2,
+		BorderColor: white,
+		Background:  Background{Color: black},
+		Foreground:  white,
+		Opacity:     1,
+	}
+}
+
+func textBounds(l *Layout) pixel.Rect {
+	var u pixel.Rect
+	for _, run := range l.Text {
+		if run.Bounds.W()*run.Bounds.H() == 0 {
+			continue
+		}
+		u = extendBounds(u, run.Bounds)
+	}
+	return u
+}
+
+func TestNew_FitsText(t *testing.T) {
+	cfg := testConfig(t)
+	l := New(cfg, &parsing.Notification{Title: "Title", Body: "Body\nline two"})
+
+	if len(l.Text) != 3 {
+		t.Fatalf("got %d text runs, want 3", len(l.Text))
+	}
+	if !l.Text[0].Bold || l.Text[1].Bold || l.Text[2].Bold {
+		t.Error("want bold title and regular body")
+	}
+	for i := 1; i < len(l.Text); i++ {
+		if l.Text[i].Dot.Y >= l.Text[i-1].Dot.Y {
+			t.Errorf("run %d is not below run %d", i, i-1)
+		}
+	}
+
+	tb := textBounds(l)
+	inset := cfg.Padding + cfg.BorderWidth
+	if math.Abs(tb.Min.X-inset) > 1e-9 || math.Abs(tb.Min.Y-inset) > 1e-9 {
+		t.Errorf("got text origin %v, want (%v, %v)", tb.Min, inset, inset)
+	}
+	if math.Abs(l.Width-tb.W()-2*inset) > 1e-9 ||
+		math.Abs(l.Height-tb.H()-2*inset) > 1e-9 {
+		t.Errorf("window %vx%v does not fit text %v", l.Width, l.Height, tb)
+	}
+}
+
+func TestNew_FixedSizeCentersText(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Width, cfg.Height = 300, 200
+	l := New(cfg, &parsing.Notification{Body: "centered"})
+
+	if l.Width != 300 || l.Height != 200 {
+		t.Fatalf("got size %vx%v, want 300x200", l.Width, l.Height)
+	}
+	// The union with the zero rect of the missing title includes the origin
+	// of the text, just like pixel's text bounds do.
+	tb := textBounds(l).Union(pixel.Rect{Min: l.Text[0].Dot, Max: l.Text[0].Dot})
+	if c := tb.Center(); math.Abs(c.X-150) > 1e-9 || math.Abs(c.Y-100) > 1e-9 {
+		t.Errorf("got text center %v, want (150, 100)", c)
+	}
+}
+
+func TestNew_Boxes(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Width, cfg.Height = 100, 50
+	cfg.BorderWidth = 5
+	l := New(cfg, &parsing.Notification{Body: "x"})
+
+	want := pixel.R(5, 5, 95, 45)
+	if l.Content.Rect != want {
+		t.Errorf("got content %v, want %v", l.Content.Rect, want)
+	}
+	var area float64
+	for _, b := range l.Border {
+		area += b.Rect.Area()
+		if b.Rect.Intersect(l.Content.Rect).Area() > 0 {
+			t.Errorf("border strip %v overlaps content", b.Rect)
+		}
+	}
+	if area != 100*50-90*40 {
+		t.Errorf("got border area %v, want %v", area, 100*50-90*40)
+	}
+
+	cfg.BorderWidth = 0
+	l = New(cfg, &parsing.Notification{Body: "x"})
+	if len(l.Border) != 0 {
+		t.Errorf("got %d border strips, want none", len(l.Border))
+	}
+}
+
+func TestLayout_Opaque(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Background.Color = translucent
+	l := New(cfg, &parsing.Notification{Body: "x"})
+	if !l.IsTranslucent() {
+		t.Fatal("want translucent layout")
+	}
+	ol := l.Opaque()
+	if ol.IsTranslucent() {
+		t.Error("want opaque layout")
+	}
+	if l.Content.Color != translucent {
+		t.Error("want original layout to be unchanged")
+	}
+
+	cfg = testConfig(t)
+	cfg.Opacity = 0.5
+	if !New(cfg, &parsing.Notification{Body: "x"}).IsTranslucent() {
+		t.Error("want translucent layout for opacity < 1")
+	}
+}
+
+func TestGlyphBounds(t *testing.T) {
+	cfg := testConfig(t)
+	l := New(cfg, &parsing.Notification{Body: "a b"})
+	glyphs := l.Text[0].GlyphBounds()
+	if len(glyphs) != 3 {
+		t.Fatalf("got %d glyphs, want 3", len(glyphs))
+	}
+	if glyphs[1].W()*glyphs[1].H() != 0 {
+		t.Errorf("want empty bounds for space, got %v", glyphs[1])
+	}
+	if !(glyphs[0].Max.X <= glyphs[2].Min.X) {
+		t.Errorf("glyph %v is not left of %v", glyphs[0], glyphs[2])
+	}
+}
+
+func TestPlaceImage(t *testing.T) {
+	src := image.Rect(0, 0, 40, 20)
+	tests := []struct {
+		name string
+		mode parsing.ImageMode
+		dst  pixel.Rect
+		want []ImagePlacement
+	}{
+		{
+			"stretch",
+			parsing.ImageStretch,
+			pixel.R(0, 0, 100, 100),
+			[]ImagePlacement{{src, pixel.R(0, 0, 100, 100)}},
+		},
+		{
+			"cover wide",
+			parsing.ImageCover,
+			pixel.R(0, 0, 20, 20),
+			[]ImagePlacement{{image.Rect(10, 0, 30, 20), pixel.R(0, 0, 20, 20)}},
+		},
+		{
+			"cover tall",
+			parsing.ImageCover,
+			pixel.R(0, 0, 80, 20),
+			[]ImagePlacement{{image.Rect(0, 5, 40, 15), pixel.R(0, 0, 80, 20)}},
+		},
+		{
+			"tile",
+			parsing.ImageTile,
+			pixel.R(0, 0, 50, 30),
+			[]ImagePlacement{
+				{image.Rect(0, 0, 40, 20), pixel.R(0, 10, 40, 30)},
+				{image.Rect(0, 0, 10, 20), pixel.R(40, 10, 50, 30)},
+				{image.Rect(0, 0, 40, 10), pixel.R(0, 0, 40, 10)},
+				{image.Rect(0, 0, 10, 10), pixel.R(40, 0, 50, 10)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := placeImage(src, tt.mode, tt.dst)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    image.Rectangle
+		wantErr bool
+	}{
+		{"image", image.Rect(0, 0, 2, 1), false},
+		{"empty", image.Rect(0, 0, 0, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			img := image.NewPaletted(tt.size, color.Palette{color.Black})
+			if err := gif.Encode(&b, img, nil); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "bg.gif")
+			if err := os.WriteFile(path, b.Bytes(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadImage(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew_Links(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.LinkColor = translucent
+	l := New(cfg, &parsing.Notification{
+		Title: "https://title.example.com",
+		Body:  "first line\nsee https://example.com/42.",
+	})
+
+	if len(l.Text[0].Links) != 0 || len(l.Text[1].Links) != 0 {
+		t.Error("want links only where the body has them")
+	}
+	run := l.Text[2]
+	if len(run.Links) != 1 {
+		t.Fatalf("got %d links, want 1", len(run.Links))
+	}
+	link := run.Links[0]
+	if link.URL != "https://example.com/42" || link.Start != 4 || link.End != 26 {
+		t.Errorf("got link %q at %d-%d", link.URL, link.Start, link.End)
+	}
+	if link.Color != translucent {
+		t.Errorf("got link color %v, want %v", link.Color, translucent)
+	}
+	glyphs := run.GlyphBounds()
+	if link.Bounds.Min.X != glyphs[4].Min.X || link.Bounds.Max.X != glyphs[25].Max.X {
+		t.Errorf("got link bounds %v, want the glyphs of the URL", link.Bounds)
+	}
+	if link.Underline.Max.Y > run.Dot.Y || link.Underline.W() != link.Bounds.W() {
+		t.Errorf("got underline %v below baseline %v", link.Underline, run.Dot.Y)
+	}
+
+	tests := []struct {
+		v    pixel.Vec
+		want string
+	}{
+		{link.Bounds.Center(), link.URL},
+		{glyphs[1].Center(), ""},
+		{l.Text[0].Bounds.Center(), ""},
+	}
+	for _, tt := range tests {
+		got, ok := l.LinkAt(tt.v)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("LinkAt(%v) = %q, %v, want %q", tt.v, got, ok, tt.want)
+		}
+	}
+}
+
+func TestLayout_Inverted(t *testing.T) {
+	cfg := testConfig(t)
+	l := New(cfg, &parsing.Notification{Title: "Title", Body: "see https://example.com"})
+	il := l.Inverted()
+
+	if il.Content.Color != white || il.Content.Rect != l.Content.Rect {
+		t.Errorf("got background %v in %v, want %v", il.Content.Color, il.Content.Rect, white)
+	}
+	for _, run := range il.Text {
+		if run.Color != black {
+			t.Errorf("got text color %v, want %v", run.Color, black)
+		}
+		for _, link := range run.Links {
+			if link.Color != black {
+				t.Errorf("got link color %v, want %v", link.Color, black)
+			}
+		}
+	}
+	if l.Content.Color != black || l.Text[0].Color != white || l.Text[1].Links[0].Color != white {
+		t.Error("want the original layout unchanged")
+	}
+}
+
+func TestNew_Markdown(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Markdown = true
+	l := New(cfg, &parsing.Notification{
+		Title: "Release",
+		Body:  "- run `make`\n  - nested\n```\n$ make test\n```",
+	})
+
+	type run struct {
+		text string
+		line int
+	}
+	var got []run
+	for _, r := range l.Text {
+		got = append(got, run{r.Text, r.Line})
+	}
+	want := []run{
+		{"Release", 0},
+		{"•", 1}, {"run ", 1}, {"make", 1},
+		{"•", 2}, {"nested", 2},
+		{"$ make test", 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got runs %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("run %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	bullet, item, nestedBullet, nested := l.Text[1], l.Text[2], l.Text[4], l.Text[5]
+	if item.Dot.X <= bullet.Dot.X || nestedBullet.Dot.X != item.Dot.X || nested.Dot.X <= nestedBullet.Dot.X {
+		t.Errorf(
+			"got bullets at %v and %v and items at %v and %v, want hanging indents",
+			bullet.Dot.X, nestedBullet.Dot.X, item.Dot.X, nested.Dot.X,
+		)
+	}
+	if l.Text[3].Face != cfg.Fonts.Mono || l.Text[6].Face != cfg.Fonts.Mono {
+		t.Error("want code in the monospace face")
+	}
+
+	// The background of the inline code and the fill and the 4 strips of
+	// the border of the code block.
+	if len(l.Blocks) != 6 {
+		t.Fatalf("got %d blocks, want 6", len(l.Blocks))
+	}
+	if code := l.Blocks[0].Rect; !code.Contains(l.Text[3].Bounds.Min) ||
+		!code.Contains(l.Text[3].Bounds.Max) {
+		t.Errorf("got inline code background %v, want it around %v", code, l.Text[3].Bounds)
+	}
+	block := l.Blocks[1].Rect
+	if !block.Contains(l.Text[6].Bounds.Min) || !block.Contains(l.Text[6].Bounds.Max) {
+		t.Errorf("got code block %v, want it around %v", block, l.Text[6].Bounds)
+	}
+	if block.Max.Y > nested.Bounds.Min.Y {
+		t.Errorf("code block %v overlaps the line above %v", block, nested.Bounds)
+	}
+	inset := cfg.Padding + cfg.BorderWidth
+	if math.Abs(l.Blocks[5].Rect.Max.X-(l.Width-inset)) > 1e-9 ||
+		math.Abs(l.Blocks[3].Rect.Min.Y-inset) > 1e-9 {
+		t.Errorf("window %vx%v does not fit code block %v", l.Width, l.Height, block)
+	}
+}
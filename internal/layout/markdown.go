@@ -0,0 +1,162 @@
+package layout
+
+import (
+	"image/color"
+	"math"
+
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+)
+
+// Alpha of the tinted background of code and of the border of code blocks,
+// which are drawn in the foreground color.
+const (
+	codeTint   = 0x30
+	codeBorder = 0x80
+)
+
+// markdownLayout lays out the blocks of a Markdown body line by line from
+// dot down, like New does with text.
+type markdownLayout struct {
+	fonts     *ifont.FontSet
+	fg        color.Color
+	linkColor color.Color
+
+	dot    pixel.Vec
+	row    int
+	runs   []TextRun
+	blocks []Box
+	acc    pixel.Rect
+}
+
+func (ml *markdownLayout) add(blocks []parsing.Block) {
+	regular := ml.fonts.Regular
+	// The text of list items hangs at the indent of their level, with the
+	// bullet in front of it.
+	indent := advance(regular, Bullet) + advance(regular, ' ')
+	for _, b := range blocks {
+		switch b.Kind {
+		case parsing.ParagraphBlock:
+			for _, spans := range b.Lines {
+				ml.addLine(spans, 0)
+			}
+		case parsing.ListItemBlock:
+			x := float64(b.Level) * indent
+			ml.addText(string(Bullet), regular, pixel.V(x, ml.dot.Y), false)
+			for _, spans := range b.Lines {
+				ml.addLine(spans, x+indent)
+			}
+		case parsing.CodeBlock:
+			ml.addCodeBlock(b.Lines)
+		}
+	}
+}
+
+// padding returns the space between code and its background.
+func (ml *markdownLayout) padding() float64 {
+	return math.Round(lineHeight(ml.fonts.Regular) / 8)
+}
+
+// addLine adds the spans of a line starting at x and moves dot to the next
+// line. Inline code is drawn in the monospace face on a tinted background.
+func (ml *markdownLayout) addLine(spans []parsing.Span, x float64) {
+	var (
+		dot  = pixel.V(x, ml.dot.Y)
+		pad  = ml.padding()
+		mono = ml.fonts.Mono
+		lh   = lineHeight(ml.fonts.Regular)
+	)
+	for _, span := range spans {
+		if !span.Code {
+			dot = ml.addText(span.Text, ml.fonts.Regular, dot, true)
+			continue
+		}
+		start := dot.X
+		dot = ml.addText(span.Text, mono, dot.Add(pixel.V(pad, 0)), false)
+		ml.addBlock(Box{
+			Rect: pixel.R(
+				start,
+				dot.Y-i2f(mono.Metrics().Descent),
+				dot.X+pad,
+				dot.Y+i2f(mono.Metrics().Ascent),
+			),
+			Color: withAlpha(ml.fg, codeTint),
+		})
+		dot.X += pad
+		lh = math.Max(lh, lineHeight(mono))
+	}
+	ml.dot.Y -= lh
+	ml.row++
+}
+
+// addCodeBlock adds the lines of a code block in a bordered box.
+func (ml *markdownLayout) addCodeBlock(lines [][]parsing.Span) {
+	if len(lines) == 0 {
+		return
+	}
+	var (
+		mono   = ml.fonts.Mono
+		inset  = 2 * ml.padding()
+		border = math.Max(1, math.Round(lineHeight(mono)/16))
+		right  float64
+	)
+	ml.dot.Y -= inset + border
+	top := ml.dot.Y + i2f(mono.Metrics().Ascent) + inset
+	for _, spans := range lines {
+		dot := pixel.V(border+inset, ml.dot.Y)
+		for _, span := range spans {
+			dot = ml.addText(span.Text, mono, dot, false)
+		}
+		right = math.Max(right, dot.X)
+		ml.dot.Y -= lineHeight(mono)
+		ml.row++
+	}
+	bottom := ml.dot.Y + lineHeight(mono) - i2f(mono.Metrics().Descent) - inset
+	ml.dot.Y -= inset + border
+
+	inner := pixel.R(border, bottom, right+inset, top)
+	outer := pixel.R(0, bottom-border, right+inset+border, top+border)
+	ml.addBlock(Box{Rect: inner, Color: withAlpha(ml.fg, codeTint)})
+	for _, b := range frame(outer, inner, withAlpha(ml.fg, codeBorder)) {
+		ml.addBlock(b)
+	}
+}
+
+// addText adds a run of text at dot, which is on the current line, and
+// returns the dot after it.
+func (ml *markdownLayout) addText(
+	text string,
+	face font.Face,
+	dot pixel.Vec,
+	links bool,
+) pixel.Vec {
+	glyphs, acc, end := layoutLine(face, text, dot, ml.acc)
+	ml.acc = acc
+	run := TextRun{
+		Text:   text,
+		Face:   face,
+		Color:  ml.fg,
+		Dot:    dot,
+		Bounds: unionGlyphs(glyphs),
+		Line:   ml.row,
+	}
+	if links {
+		run.Links = layoutLinks(text, glyphs, face, dot, ml.linkColor)
+	}
+	ml.runs = append(ml.runs, run)
+	return end
+}
+
+func (ml *markdownLayout) addBlock(b Box) {
+	ml.blocks = append(ml.blocks, b)
+	ml.acc = extendBounds(ml.acc, b.Rect)
+}
+
+// withAlpha returns c with the alpha a.
+func withAlpha(c color.Color, a uint8) color.Color {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	nc.A = a
+	return nc
+}
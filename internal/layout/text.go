@@ -0,0 +1,110 @@
+package layout
+
+import (
+	"math"
+	"unicode"
+
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// The metrics below mirror those of github.com/faiface/pixel/text with an
+// atlas of ASCII and the bullet, so that every backend places glyphs like
+// pixelgl does.
+
+func i2f(i fixed.Int26_6) float64 {
+	return float64(i) / (1 << 6)
+}
+
+// Bullet is drawn in front of list items. It is the only rune besides
+// ASCII in the atlas.
+const Bullet = '•'
+
+func atlasRune(r rune) rune {
+	if r == Bullet {
+		return r
+	}
+	if r < ' ' || r > '~' {
+		return unicode.ReplacementChar
+	}
+	return r
+}
+
+func lineHeight(face font.Face) float64 {
+	return i2f(face.Metrics().Height)
+}
+
+func advance(face font.Face, r rune) float64 {
+	_, adv, _ := face.GlyphBounds(atlasRune(r))
+	return i2f(adv)
+}
+
+// extendBounds extends the accumulated text bounds acc by the bounds b of a
+// glyph. Like pixel's text, empty accumulated bounds are replaced instead of
+// extended.
+func extendBounds(acc, b pixel.Rect) pixel.Rect {
+	if acc.W()*acc.H() == 0 {
+		return b
+	}
+	return acc.Union(b)
+}
+
+// layoutLine returns the bounds of every rune of line when drawn with its
+// baseline origin at dot, together with the accumulated text bounds acc
+// extended by the line and the dot after the line. Control runes have no
+// bounds.
+func layoutLine(
+	face font.Face,
+	line string,
+	dot pixel.Vec,
+	acc pixel.Rect,
+) ([]pixel.Rect, pixel.Rect, pixel.Vec) {
+	var (
+		glyphs   []pixel.Rect
+		orig     = dot
+		prev     = rune(-1)
+		tabWidth = advance(face, ' ') * 4
+		ascent   = i2f(face.Metrics().Ascent)
+		descent  = i2f(face.Metrics().Descent)
+	)
+	for _, r := range line {
+		switch r {
+		case '\r':
+			dot.X = orig.X
+			glyphs = append(glyphs, pixel.Rect{Min: dot, Max: dot})
+			continue
+		case '\t':
+			rem := math.Mod(dot.X-orig.X, tabWidth)
+			rem = math.Mod(rem, rem+tabWidth)
+			if rem == 0 {
+				rem = tabWidth
+			}
+			glyphs = append(glyphs, pixel.R(dot.X, dot.Y, dot.X+rem, dot.Y))
+			dot.X += rem
+			continue
+		}
+
+		r = atlasRune(r)
+		if prev >= 0 {
+			dot.X += i2f(face.Kern(prev, r))
+		}
+		b, adv, _ := face.GlyphBounds(r)
+		minX, maxX := float64(b.Min.X.Floor()), float64(b.Max.X.Ceil())
+		minY, maxY := float64(b.Min.Y.Floor()), float64(b.Max.Y.Ceil())
+		glyph := pixel.Rect{Min: dot, Max: dot}
+		if (maxX-minX)*(maxY-minY) != 0 {
+			glyph = pixel.R(
+				dot.X+minX,
+				dot.Y-descent,
+				dot.X+maxX,
+				dot.Y+ascent,
+			)
+		}
+		glyphs = append(glyphs, glyph)
+		acc = extendBounds(acc, glyph)
+		dot.X += i2f(adv)
+		prev = r
+	}
+	return glyphs, acc, dot
+}
@@ -0,0 +1,131 @@
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// handoverTimeout bounds how long either side of a handover waits for the
+// other.
+const handoverTimeout = 2 * time.Second
+
+var ErrNoListener = errors.New("no notification with this id is shown")
+
+// SocketPath returns the path of the socket of the notification id in dir.
+func SocketPath(dir, id string) (string, error) {
+	if !validName(id) {
+		return "", fmt.Errorf("invalid id %q", id)
+	}
+	return filepath.Join(dir, id+".sock"), nil
+}
+
+// DedupPath returns the path of the socket of the notifications of channel
+// in dir whose content is the same.
+func DedupPath(dir, channel, content string) (string, error) {
+	return hashedPath(dir, "dedup", channel, content)
+}
+
+// GroupPath returns the path of the socket of the notifications of channel
+// in dir that belong to the group key.
+func GroupPath(dir, channel, key string) (string, error) {
+	return hashedPath(dir, "group", channel, key)
+}
+
+// hashedPath returns the path of a socket named after a hash of channel and
+// s, which may contain any characters.
+func hashedPath(dir, kind, channel, s string) (string, error) {
+	if !validName(channel) {
+		return "", fmt.Errorf("invalid channel name %q", channel)
+	}
+	sum := sha256.Sum256([]byte(channel + "\x00" + s))
+	return filepath.Join(dir, kind+"-"+hex.EncodeToString(sum[:8])+".sock"), nil
+}
+
+// Handover sends input to the process listening on the socket at path. It
+// returns ErrNoListener if no process listens or if the process did not
+// take over the input, e.g. because its notification closed meanwhile.
+func Handover(path string, input []byte) error {
+	conn, err := net.DialTimeout("unix", path, handoverTimeout)
+	if err != nil {
+		return ErrNoListener
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(handoverTimeout))
+
+	if _, err := conn.Write(input); err != nil {
+		return ErrNoListener
+	}
+	if err := conn.(*net.UnixConn).CloseWrite(); err != nil {
+		return fmt.Errorf("could not hand over notification: %w", err)
+	}
+	ack := make([]byte, 1)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return ErrNoListener
+	}
+	return nil
+}
+
+type Listener struct {
+	ln *net.UnixListener
+}
+
+// Listen listens on the socket at path and calls take with the input of
+// every handover, one at a time. take reports whether it took over the
+// input. A socket left behind by a process that has exited is replaced.
+func Listen(path string, take func(input []byte) bool) (*Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("could not create lock directory: %w", err)
+	}
+	addr := &net.UnixAddr{Name: path, Net: "unix"}
+	ln, err := net.ListenUnix("unix", addr)
+	if errors.Is(err, unix.EADDRINUSE) {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("could not listen: %s is in use", path)
+		}
+		os.Remove(path)
+		ln, err = net.ListenUnix("unix", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not listen: %w", err)
+	}
+	l := Listener{ln: ln}
+	go l.serve(take)
+	return &l, nil
+}
+
+func (l *Listener) serve(take func(input []byte) bool) {
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return
+		}
+		receive(conn, take)
+	}
+}
+
+func receive(conn net.Conn, take func(input []byte) bool) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(handoverTimeout))
+	input, err := io.ReadAll(conn)
+	if err != nil {
+		return
+	}
+	if take(input) {
+		conn.Write([]byte{1})
+	}
+}
+
+// Close stops listening and removes the socket.
+func (l *Listener) Close() error {
+	return l.ln.Close()
+}
@@ -0,0 +1,120 @@
+package lock
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSocketPath(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"volume", "/run/notify/volume.sock", false},
+		{"", "", true},
+		{".", "", true},
+		{"a/b", "", true},
+	}
+	for _, tt := range tests {
+		got, err := SocketPath("/run/notify", tt.id)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SocketPath(%q): got error %v, want error %v", tt.id, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SocketPath(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestDedupPath(t *testing.T) {
+	path := func(channel, content string) string {
+		t.Helper()
+		p, err := DedupPath("/run/notify", channel, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a := path("default", "[Tests]failed")
+	if filepath.Dir(a) != "/run/notify" || filepath.Ext(a) != ".sock" {
+		t.Errorf("got path %q, want a socket in /run/notify", a)
+	}
+	if b := path("default", "[Tests]failed"); a != b {
+		t.Errorf("same content: got %q and %q", a, b)
+	}
+	if b := path("default", "[Tests]passed"); a == b {
+		t.Errorf("different content: got %q for both", a)
+	}
+	if b := path("build", "[Tests]failed"); a == b {
+		t.Errorf("different channel: got %q for both", a)
+	}
+	if g, err := GroupPath("/run/notify", "default", "[Tests]failed"); err != nil || g == a {
+		t.Errorf("got group path %q (error %v), want a path other than %q", g, err, a)
+	}
+	if _, err := DedupPath("/run/notify", "a/b", "x"); err == nil {
+		t.Error("want error for invalid channel")
+	}
+}
+
+func TestHandover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify", "volume.sock")
+	if err := Handover(path, []byte("Volume 50%")); !errors.Is(err, ErrNoListener) {
+		t.Fatalf("got error %v without listener, want ErrNoListener", err)
+	}
+
+	inputs := make(chan string, 1)
+	accept := true
+	l, err := Listen(path, func(input []byte) bool {
+		if accept {
+			inputs <- string(input)
+		}
+		return accept
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Handover(path, []byte("Volume 60%")); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-inputs; got != "Volume 60%" {
+		t.Errorf("got input %q, want %q", got, "Volume 60%")
+	}
+
+	accept = false
+	if err := Handover(path, []byte("Volume 70%")); !errors.Is(err, ErrNoListener) {
+		t.Errorf("got error %v from refusing listener, want ErrNoListener", err)
+	}
+
+	if _, err := Listen(path, func([]byte) bool { return true }); err == nil {
+		t.Error("listening on a socket in use succeeded")
+	}
+
+	l.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket was not removed: %v", err)
+	}
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "volume.sock")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.SetUnlinkOnClose(false)
+	ln.Close()
+
+	l, err := Listen(path, func([]byte) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := Handover(path, []byte("Volume 60%")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,145 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Policy decides what happens if the lock is held by another notification.
+type Policy int
+
+const (
+	// Wait blocks until the lock is released.
+	Wait Policy = iota
+	// NoWait gives up and returns ErrLocked.
+	NoWait
+	// Replace asks the holder to close its notification with
+	// ReplaceSignal and takes over the lock.
+	Replace
+)
+
+// ReplaceSignal is sent to the holder of a lock that is to be replaced, and
+// to notifications that are dismissed while waiting for the lock. Processes
+// must handle it before acquiring the lock, as it terminates them otherwise.
+const ReplaceSignal = unix.SIGUSR1
+
+var ErrLocked = errors.New("lock is held by another notification")
+
+// replaceInterval is how often the holder of a lock is signaled until it
+// has released the lock.
+const replaceInterval = 50 * time.Millisecond
+
+const DefaultChannel = "default"
+
+// Dir returns the default directory of the lock files, which is private to
+// the user.
+func Dir(app string) string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, app)
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d", app, os.Getuid()))
+}
+
+// Path returns the path of the lock file of channel in dir.
+func Path(dir, channel string) (string, error) {
+	if !validName(channel) {
+		return "", fmt.Errorf("invalid channel name %q", channel)
+	}
+	return filepath.Join(dir, channel+".lock"), nil
+}
+
+// validName reports whether name can be used as a file name in the lock
+// directory.
+func validName(name string) bool {
+	return name != "" &&
+		name != "." &&
+		name != ".." &&
+		!strings.ContainsAny(name, "/\x00")
+}
+
+type Lock struct {
+	file *os.File
+}
+
+// Acquire locks the file at path, creating it and its directory if needed.
+// The lock file holds the pid of the holder.
+func Acquire(path string, policy Policy) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("could not create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+	fd := int(file.Fd())
+
+	switch policy {
+	case Wait:
+		err = unix.Flock(fd, unix.LOCK_EX)
+	case NoWait:
+		err = unix.Flock(fd, unix.LOCK_EX|unix.LOCK_NB)
+		if err == unix.EWOULDBLOCK {
+			err = ErrLocked
+		}
+	case Replace:
+		for {
+			err = unix.Flock(fd, unix.LOCK_EX|unix.LOCK_NB)
+			if err != unix.EWOULDBLOCK {
+				break
+			}
+			signalHolder(file)
+			time.Sleep(replaceInterval)
+		}
+	}
+	if err != nil {
+		file.Close()
+		if err == ErrLocked {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not acquire lock: %w", err)
+	}
+
+	l := Lock{file: file}
+	if err := l.writePid(); err != nil {
+		l.Release()
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (l *Lock) writePid() error {
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+	if _, err := l.file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+	return nil
+}
+
+// signalHolder sends ReplaceSignal to the pid in the lock file. The holder
+// may not have written its pid yet, which is caught by trying again.
+func signalHolder(file *os.File) {
+	buf := make([]byte, 32)
+	n, _ := file.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil || pid <= 0 {
+		return
+	}
+	unix.Kill(pid, ReplaceSignal)
+}
+
+// Release unlocks the lock. The pid is removed first, so that it is not
+// signaled after the process has exited and the pid may have been reused.
+func (l *Lock) Release() error {
+	defer l.file.Close()
+	l.file.Truncate(0)
+	return unix.Flock(int(l.file.Fd()), unix.LOCK_UN)
+}
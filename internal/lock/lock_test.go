@@ -0,0 +1,98 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		channel string
+		want    string
+		wantErr bool
+	}{
+		{"default", "/run/notify/default.lock", false},
+		{"build", "/run/notify/build.lock", false},
+		{"", "", true},
+		{"..", "", true},
+		{"a/b", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Path("/run/notify", tt.channel)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Path(%q): got error %v, want error %v", tt.channel, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Path(%q) = %q, want %q", tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := Dir("notify"); got != "/run/user/1000/notify" {
+		t.Errorf("got %q, want /run/user/1000/notify", got)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	want := filepath.Join(os.TempDir(), "notify-"+strconv.Itoa(os.Getuid()))
+	if got := Dir("notify"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "default.lock")
+	held, err := Acquire(path, Wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("got pid %q, want %d", b, os.Getpid())
+	}
+
+	if _, err := Acquire(path, NoWait); !errors.Is(err, ErrLocked) {
+		t.Fatalf("got error %v, want %v", err, ErrLocked)
+	}
+
+	other, err := Acquire(filepath.Join(filepath.Dir(path), "build.lock"), NoWait)
+	if err != nil {
+		t.Fatalf("want independent channels, got %v", err)
+	}
+	other.Release()
+
+	// The holder is this process, which releases its lock when asked to.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, ReplaceSignal)
+	defer signal.Stop(sigs)
+	go func() {
+		<-sigs
+		held.Release()
+	}()
+
+	done := make(chan *Lock)
+	go func() {
+		l, err := Acquire(path, Replace)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- l
+	}()
+	select {
+	case l := <-done:
+		if l != nil {
+			l.Release()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not replaced")
+	}
+}
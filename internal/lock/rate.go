@@ -0,0 +1,64 @@
+package lock
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// RatePath returns the path of the file that records when notifications of
+// channel in dir were sent.
+func RatePath(dir, channel string) (string, error) {
+	if !validName(channel) {
+		return "", fmt.Errorf("invalid channel name %q", channel)
+	}
+	return filepath.Join(dir, channel+".rate"), nil
+}
+
+// Allow reports whether fewer than count notifications were sent within
+// period before now, according to the file at path. If so, now is recorded
+// as another notification.
+func Allow(path string, count int, period time.Duration, now time.Time) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return false, fmt.Errorf("could not create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return false, fmt.Errorf("could not open rate file: %w", err)
+	}
+	defer file.Close()
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX); err != nil {
+		return false, fmt.Errorf("could not lock rate file: %w", err)
+	}
+
+	b, err := io.ReadAll(file)
+	if err != nil {
+		return false, fmt.Errorf("could not read rate file: %w", err)
+	}
+	var recent []string
+	for _, line := range strings.Fields(string(b)) {
+		ns, err := strconv.ParseInt(line, 10, 64)
+		if err == nil && now.Sub(time.Unix(0, ns)) < period {
+			recent = append(recent, line)
+		}
+	}
+	allowed := len(recent) < count
+	if allowed {
+		recent = append(recent, strconv.FormatInt(now.UnixNano(), 10))
+	}
+
+	if err := file.Truncate(0); err != nil {
+		return false, fmt.Errorf("could not write rate file: %w", err)
+	}
+	data := strings.Join(recent, "\n")
+	if _, err := file.WriteAt([]byte(data), 0); err != nil {
+		return false, fmt.Errorf("could not write rate file: %w", err)
+	}
+	return allowed, nil
+}
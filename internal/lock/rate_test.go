@@ -0,0 +1,38 @@
+package lock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	path, err := RatePath(filepath.Join(t.TempDir(), "notify"), "tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		after time.Duration
+		want  bool
+	}{
+		{0, true},
+		{10 * time.Second, true},
+		{20 * time.Second, true},
+		{30 * time.Second, false},
+		// The first notification has left the period.
+		{60 * time.Second, true},
+		{61 * time.Second, false},
+		// Rejected notifications are not counted.
+		{71 * time.Second, true},
+	}
+	for _, tt := range tests {
+		got, err := Allow(path, 3, time.Minute, start.Add(tt.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Allow after %v = %v, want %v", tt.after, got, tt.want)
+		}
+	}
+}
@@ -4,38 +4,249 @@ import (
 	"errors"
 	"fmt"
 	"image/color"
+	"math"
+	"strconv"
+	"strings"
 )
 
+// ParseColor parses a color in hex, named, functional or Xlib notation.
+// Note that the returned color holds straight, i.e. not premultiplied, alpha.
 func ParseColor(input string) (color.RGBA, error) {
 	var (
 		clr color.RGBA
 		err error
 	)
 
-	switch len(input) {
-	case 4:
-		_, err = fmt.Sscanf(input, "#%1x%1x%1x", &clr.R, &clr.G, &clr.B)
-		clr.R |= clr.R << 4
-		clr.G |= clr.G << 4
-		clr.B |= clr.B << 4
-		clr.A = 0xff
-	case 7:
-		_, err = fmt.Sscanf(input, "#%02x%02x%02x", &clr.R, &clr.G, &clr.B)
-		clr.A = 0xff
-	case 9:
-		_, err = fmt.Sscanf(
-			input,
-			"#%02x%02x%02x%02x",
-			&clr.R,
-			&clr.G,
-			&clr.B,
-			&clr.A,
-		)
+	s := strings.TrimSpace(input)
+	switch {
+	case strings.HasPrefix(s, "#"):
+		clr, err = parseHexColor(s[1:])
+	case strings.HasPrefix(s, "rgb:"):
+		clr, err = parseXColor(s[len("rgb:"):])
+	case strings.HasSuffix(s, ")"):
+		clr, err = parseFunctionalColor(s)
+	case s == "":
+		err = errors.New("empty input")
 	default:
-		err = errors.New("unexpected input length")
+		var ok bool
+		clr, ok = lookupNamedColor(s)
+		if !ok {
+			err = errors.New("unknown color name")
+		}
 	}
 	if err != nil {
 		return clr, fmt.Errorf("could not parse color %s: %w", input, err)
 	}
 	return clr, nil
 }
+
+// parseHexColor parses the digits of #rgb, #rgba, #rrggbb and #rrggbbaa.
+func parseHexColor(digits string) (color.RGBA, error) {
+	var clr color.RGBA
+
+	values := make([]uint8, 0, 4)
+	switch len(digits) {
+	case 3, 4:
+		for i := 0; i < len(digits); i++ {
+			v, err := strconv.ParseUint(digits[i:i+1], 16, 8)
+			if err != nil {
+				return clr, err
+			}
+			values = append(values, uint8(v)|uint8(v)<<4)
+		}
+	case 6, 8:
+		for i := 0; i < len(digits); i += 2 {
+			v, err := strconv.ParseUint(digits[i:i+2], 16, 8)
+			if err != nil {
+				return clr, err
+			}
+			values = append(values, uint8(v))
+		}
+	default:
+		return clr, errors.New("unexpected input length")
+	}
+
+	clr.R, clr.G, clr.B, clr.A = values[0], values[1], values[2], 0xff
+	if len(values) == 4 {
+		clr.A = values[3]
+	}
+	return clr, nil
+}
+
+// parseXColor parses the Xlib "rgb:<red>/<green>/<blue>" notation, where each
+// component consists of one to four hex digits.
+func parseXColor(components string) (color.RGBA, error) {
+	var clr color.RGBA
+
+	ts := strings.Split(components, "/")
+	if len(ts) != 3 {
+		return clr, errors.New("expected three components")
+	}
+	values := make([]uint8, len(ts))
+	for i, t := range ts {
+		if len(t) < 1 || len(t) > 4 {
+			return clr, fmt.Errorf("unexpected length of component %q", t)
+		}
+		v, err := strconv.ParseUint(t, 16, 16)
+		if err != nil {
+			return clr, err
+		}
+		scale := math.Pow(16, float64(len(t))) - 1
+		values[i] = uint8(math.Round(float64(v) / scale * 0xff))
+	}
+
+	clr.R, clr.G, clr.B, clr.A = values[0], values[1], values[2], 0xff
+	return clr, nil
+}
+
+// parseFunctionalColor parses the CSS notations rgb(), rgba(), hsl() and
+// hsla(). Arguments may be separated by commas or, as in CSS Color Level 4,
+// by whitespace with the alpha value following a slash.
+func parseFunctionalColor(input string) (color.RGBA, error) {
+	var clr color.RGBA
+
+	s := newState(input)
+	name, s, err := lexUntil(s, '(')
+	if err != nil {
+		return clr, errors.New("expected opening parenthesis")
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	args := strings.TrimSuffix(s.remaining(), ")")
+
+	var ts []string
+	if strings.Contains(args, ",") {
+		ts = strings.Split(args, ",")
+	} else {
+		main, alpha, hasAlpha := strings.Cut(args, "/")
+		ts = strings.Fields(main)
+		if hasAlpha {
+			ts = append(ts, alpha)
+		}
+	}
+	for i := range ts {
+		ts[i] = strings.TrimSpace(ts[i])
+	}
+	if len(ts) != 3 && len(ts) != 4 {
+		return clr, fmt.Errorf("expected 3 or 4 arguments, got %d", len(ts))
+	}
+
+	alpha := 1.0
+	if len(ts) == 4 {
+		alpha, err = parseAlpha(ts[3])
+		if err != nil {
+			return clr, err
+		}
+	}
+
+	var r, g, b float64
+	switch name {
+	case "rgb", "rgba":
+		channels := make([]float64, 3)
+		for i, t := range ts[:3] {
+			channels[i], err = parseChannel(t)
+			if err != nil {
+				return clr, err
+			}
+		}
+		r, g, b = channels[0], channels[1], channels[2]
+	case "hsl", "hsla":
+		var h, sat, l float64
+		h, err = parseHue(ts[0])
+		if err != nil {
+			return clr, err
+		}
+		sat, err = parsePercentage(ts[1])
+		if err != nil {
+			return clr, err
+		}
+		l, err = parsePercentage(ts[2])
+		if err != nil {
+			return clr, err
+		}
+		r, g, b = hslToRGB(h, sat, l)
+	default:
+		return clr, fmt.Errorf("unknown color function %q", name)
+	}
+
+	clr.R = toUint8(r)
+	clr.G = toUint8(g)
+	clr.B = toUint8(b)
+	clr.A = toUint8(alpha)
+	return clr, nil
+}
+
+func parseChannel(token string) (float64, error) {
+	if strings.HasSuffix(token, "%") {
+		return parsePercentage(token)
+	}
+	v, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, err
+	}
+	return clamp(v/0xff, 0, 1), nil
+}
+
+func parseAlpha(token string) (float64, error) {
+	if strings.HasSuffix(token, "%") {
+		return parsePercentage(token)
+	}
+	v, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, err
+	}
+	return clamp(v, 0, 1), nil
+}
+
+func parsePercentage(token string) (float64, error) {
+	if !strings.HasSuffix(token, "%") {
+		return 0, fmt.Errorf("expected percentage, got %q", token)
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(token, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	return clamp(v/100, 0, 1), nil
+}
+
+func parseHue(token string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(token, "deg"), 64)
+	if err != nil {
+		return 0, err
+	}
+	v = math.Mod(v, 360)
+	if v < 0 {
+		v += 360
+	}
+	return v, nil
+}
+
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+func toUint8(v float64) uint8 {
+	return uint8(math.Round(clamp(v, 0, 1) * 0xff))
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -16,6 +16,27 @@ func TestParseColor_ValidInput(t *testing.T) {
 		{"#000", color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}},
 		{"#0e0e0e", color.RGBA{R: 0x0e, G: 0x0e, B: 0x0e, A: 0xff}},
 		{"#0f0", color.RGBA{R: 0x00, G: 0xff, B: 0x00, A: 0xff}},
+		{"#0f08", color.RGBA{R: 0x00, G: 0xff, B: 0x00, A: 0x88}},
+		{"#ff000080", color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x80}},
+		{"red", color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xff}},
+		{"SteelBlue", color.RGBA{R: 0x46, G: 0x82, B: 0xb4, A: 0xff}},
+		{"steel blue", color.RGBA{R: 0x46, G: 0x82, B: 0xb4, A: 0xff}},
+		{"gray", color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}},
+		{"grey50", color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}},
+		{"gray100", color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{"transparent", color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0x00}},
+		{"rgb(255, 0, 0)", color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xff}},
+		{"rgb(100%, 50%, 0%)", color.RGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}},
+		{"rgba(0, 0, 255, 0.5)", color.RGBA{R: 0x00, G: 0x00, B: 0xff, A: 0x80}},
+		{"rgb(0 0 255 / 25%)", color.RGBA{R: 0x00, G: 0x00, B: 0xff, A: 0x40}},
+		{"RGB( 1 , 2 , 3 )", color.RGBA{R: 0x01, G: 0x02, B: 0x03, A: 0xff}},
+		{"hsl(0, 100%, 50%)", color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xff}},
+		{"hsl(120deg 100% 25%)", color.RGBA{R: 0x00, G: 0x80, B: 0x00, A: 0xff}},
+		{"hsla(240, 100%, 50%, 0)", color.RGBA{R: 0x00, G: 0x00, B: 0xff, A: 0x00}},
+		{"hsl(-120, 100%, 50%)", color.RGBA{R: 0x00, G: 0x00, B: 0xff, A: 0xff}},
+		{"rgb:ff/80/00", color.RGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}},
+		{"rgb:f/8/0", color.RGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}},
+		{"rgb:ffff/0000/8080", color.RGBA{R: 0xff, G: 0x00, B: 0x80, A: 0xff}},
 	}
 	for _, tt := range tests {
 		testname := tt.input
@@ -42,6 +63,21 @@ func TestParseColor_InvalidInput(t *testing.T) {
 		"1234567",
 		"123456789",
 		"#zzz",
+		"#fffffff",
+		"notacolor",
+		"gray101",
+		"gray+5",
+		"grey-0",
+		"grayish",
+		"rgb(1, 2)",
+		"rgb(1, 2, 3, 4, 5)",
+		"rgb(a, b, c)",
+		"hsl(0, 100, 50)",
+		"cmyk(0, 0, 0, 0)",
+		"rgb(1, 2, 3",
+		"rgb:ff/ff",
+		"rgb:fffff/0/0",
+		"rgb:zz/00/00",
 	}
 	for _, ti := range testInputs {
 		testname := ti
@@ -0,0 +1,270 @@
+package parsing
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type GradientKind int
+
+const (
+	LinearGradient GradientKind = iota
+	RadialGradient
+)
+
+type ColorStop struct {
+	Color  color.RGBA
+	Offset float64
+}
+
+type Gradient struct {
+	Kind GradientKind
+	// Angle of a linear gradient in degrees, with 0 pointing upwards and
+	// increasing clockwise.
+	Angle float64
+	Stops []ColorStop
+}
+
+func IsGradient(input string) bool {
+	s := strings.ToLower(strings.TrimSpace(input))
+	return strings.HasPrefix(s, "linear-gradient(") ||
+		strings.HasPrefix(s, "radial-gradient(")
+}
+
+// ParseGradient parses the CSS-like notations
+// "linear-gradient([<angle>deg,] <color> [<offset>%], ...)" and
+// "radial-gradient(<color> [<offset>%], ...)". Without an angle, linear
+// gradients run from top to bottom. Stops without offset are distributed
+// evenly between their neighbours.
+func ParseGradient(input string) (*Gradient, error) {
+	var grad Gradient
+
+	s := newState(strings.TrimSpace(input))
+	name, s, err := lexUntil(s, '(')
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not parse gradient %s: expected opening parenthesis",
+			input,
+		)
+	}
+	args, s, err := lexBalanced(s, '(', ')')
+	if err != nil || !s.endOfInput() {
+		return nil, fmt.Errorf(
+			"could not parse gradient %s: unbalanced parentheses",
+			input,
+		)
+	}
+
+	ts := splitArguments(args)
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "linear-gradient":
+		grad.Kind = LinearGradient
+		grad.Angle = 180
+		if len(ts) > 0 && strings.HasSuffix(ts[0], "deg") {
+			grad.Angle, err = strconv.ParseFloat(
+				strings.TrimSuffix(ts[0], "deg"),
+				64,
+			)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"could not parse angle of gradient %s: %w",
+					input,
+					err,
+				)
+			}
+			ts = ts[1:]
+		}
+	case "radial-gradient":
+		grad.Kind = RadialGradient
+	default:
+		return nil, fmt.Errorf(
+			"could not parse gradient %s: unknown gradient type %q",
+			input,
+			name,
+		)
+	}
+
+	if len(ts) < 2 {
+		return nil, fmt.Errorf(
+			"could not parse gradient %s: expected at least two color stops",
+			input,
+		)
+	}
+	offsets := make([]float64, len(ts))
+	for i, t := range ts {
+		var stop ColorStop
+		stop, offsets[i], err = parseColorStop(t)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse color stop of gradient %s: %w",
+				input,
+				err,
+			)
+		}
+		grad.Stops = append(grad.Stops, stop)
+	}
+	distributeOffsets(grad.Stops, offsets)
+	return &grad, nil
+}
+
+// splitArguments splits input at commas that are not nested in
+// parentheses.
+func splitArguments(input string) []string {
+	var (
+		ts    []string
+		b     strings.Builder
+		depth int
+	)
+	for _, r := range input {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			ts = append(ts, strings.TrimSpace(b.String()))
+			b.Reset()
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return append(ts, strings.TrimSpace(b.String()))
+}
+
+// parseColorStop parses "<color> [<offset>%]". A missing offset is returned
+// as NaN.
+func parseColorStop(input string) (ColorStop, float64, error) {
+	var stop ColorStop
+
+	offset := math.NaN()
+	clr := input
+	if i := strings.LastIndexAny(input, " \t"); i >= 0 &&
+		strings.HasSuffix(input, "%") &&
+		!strings.HasSuffix(input, ")") {
+		v, err := parsePercentage(input[i+1:])
+		if err != nil {
+			return stop, offset, err
+		}
+		offset = v
+		clr = strings.TrimSpace(input[:i])
+	}
+
+	var err error
+	stop.Color, err = ParseColor(clr)
+	return stop, offset, err
+}
+
+func distributeOffsets(stops []ColorStop, offsets []float64) {
+	last := len(offsets) - 1
+	if math.IsNaN(offsets[0]) {
+		offsets[0] = 0
+	}
+	if math.IsNaN(offsets[last]) {
+		offsets[last] = 1
+	}
+	prev := offsets[0]
+	for i := 1; i <= last; i++ {
+		if math.IsNaN(offsets[i]) {
+			continue
+		}
+		offsets[i] = math.Max(offsets[i], prev)
+		prev = offsets[i]
+	}
+	for i := 1; i < last; i++ {
+		if !math.IsNaN(offsets[i]) {
+			continue
+		}
+		j := i
+		for math.IsNaN(offsets[j]) {
+			j++
+		}
+		step := (offsets[j] - offsets[i-1]) / float64(j-i+1)
+		for k := i; k < j; k++ {
+			offsets[k] = offsets[k-1] + step
+		}
+	}
+	for i := range stops {
+		stops[i].Offset = offsets[i]
+	}
+}
+
+// At returns the color of the gradient at position t, where t = 0 is the
+// start and t = 1 the end of the gradient line.
+func (g *Gradient) At(t float64) color.RGBA {
+	first, last := g.Stops[0], g.Stops[len(g.Stops)-1]
+	if t <= first.Offset {
+		return first.Color
+	}
+	if t >= last.Offset {
+		return last.Color
+	}
+	for i := 1; i < len(g.Stops); i++ {
+		a, b := g.Stops[i-1], g.Stops[i]
+		if t > b.Offset {
+			continue
+		}
+		if b.Offset == a.Offset {
+			return b.Color
+		}
+		f := (t - a.Offset) / (b.Offset - a.Offset)
+		lerp := func(x, y uint8) uint8 {
+			return uint8(math.Round(float64(x) + f*(float64(y)-float64(x))))
+		}
+		return color.RGBA{
+			R: lerp(a.Color.R, b.Color.R),
+			G: lerp(a.Color.G, b.Color.G),
+			B: lerp(a.Color.B, b.Color.B),
+			A: lerp(a.Color.A, b.Color.A),
+		}
+	}
+	return last.Color
+}
+
+func (g *Gradient) IsTranslucent() bool {
+	for _, stop := range g.Stops {
+		if stop.Color.A < 0xff {
+			return true
+		}
+	}
+	return false
+}
+
+// Opaque returns a copy of the gradient with the alpha channel of all color
+// stops set to fully opaque.
+func (g *Gradient) Opaque() *Gradient {
+	og := *g
+	og.Stops = make([]ColorStop, len(g.Stops))
+	for i, stop := range g.Stops {
+		stop.Color.A = 0xff
+		og.Stops[i] = stop
+	}
+	return &og
+}
+
+type ImageMode int
+
+const (
+	ImageCover ImageMode = iota
+	ImageStretch
+	ImageTile
+)
+
+func ParseImageMode(input string) (ImageMode, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "cover":
+		return ImageCover, nil
+	case "stretch":
+		return ImageStretch, nil
+	case "tile":
+		return ImageTile, nil
+	}
+	return 0, fmt.Errorf(
+		"could not parse image mode %s: %w",
+		input,
+		errors.New("expected cover, stretch or tile"),
+	)
+}
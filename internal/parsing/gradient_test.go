@@ -0,0 +1,163 @@
+package parsing
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+var (
+	black = color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}
+	white = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	red   = color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xff}
+)
+
+func TestParseGradient_ValidInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Gradient
+	}{
+		{
+			"linear-gradient(#000, #fff)",
+			Gradient{
+				Kind:  LinearGradient,
+				Angle: 180,
+				Stops: []ColorStop{{black, 0}, {white, 1}},
+			},
+		},
+		{
+			"linear-gradient(90deg, #000, red, #fff)",
+			Gradient{
+				Kind:  LinearGradient,
+				Angle: 90,
+				Stops: []ColorStop{{black, 0}, {red, 0.5}, {white, 1}},
+			},
+		},
+		{
+			"linear-gradient(45deg, rgb(0, 0, 0) 20%, #fff 80%)",
+			Gradient{
+				Kind:  LinearGradient,
+				Angle: 45,
+				Stops: []ColorStop{{black, 0.2}, {white, 0.8}},
+			},
+		},
+		{
+			"radial-gradient(#000, red, red, #fff 40%)",
+			Gradient{
+				Kind: RadialGradient,
+				Stops: []ColorStop{
+					{black, 0},
+					{red, 0.4 / 3},
+					{red, 0.8 / 3},
+					{white, 0.4},
+				},
+			},
+		},
+		{
+			"radial-gradient(#000 50%, #fff 20%)",
+			Gradient{
+				Kind:  RadialGradient,
+				Stops: []ColorStop{{black, 0.5}, {white, 0.5}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		testname := tt.input
+		t.Run(testname, func(t *testing.T) {
+			got, err := ParseGradient(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGradient_InvalidInput(t *testing.T) {
+	testInputs := []string{
+		"",
+		"#000",
+		"linear-gradient(#000)",
+		"linear-gradient(#000, #fff",
+		"linear-gradient(#000, #fff))",
+		"linear-gradient(xdeg, #000, #fff)",
+		"linear-gradient(#000, #ggg)",
+		"linear-gradient(#000 x%, #fff)",
+		"conic-gradient(#000, #fff)",
+	}
+	for _, ti := range testInputs {
+		testname := ti
+		t.Run(testname, func(t *testing.T) {
+			_, err := ParseGradient(ti)
+			if err == nil {
+				t.Error("want error for invalid input")
+			}
+		})
+	}
+}
+
+func TestGradientAt(t *testing.T) {
+	grad := Gradient{
+		Kind:  LinearGradient,
+		Stops: []ColorStop{{black, 0.2}, {white, 0.6}, {red, 0.6}},
+	}
+	tests := []struct {
+		t    float64
+		want color.RGBA
+	}{
+		{0, black},
+		{0.2, black},
+		{0.4, color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}},
+		{0.6, red},
+		{0.7, red},
+		{1, red},
+	}
+	for _, tt := range tests {
+		got := grad.At(tt.t)
+		if got != tt.want {
+			t.Errorf("At(%v): got %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestParseImageMode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ImageMode
+	}{
+		{"cover", ImageCover},
+		{"Stretch", ImageStretch},
+		{"tile", ImageTile},
+	}
+	for _, tt := range tests {
+		got, err := ParseImageMode(tt.input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("got %v, want %v", got, tt.want)
+		}
+	}
+	if _, err := ParseImageMode("center"); err == nil {
+		t.Error("want error for invalid input")
+	}
+}
+
+func TestGradientOpaque(t *testing.T) {
+	grad, err := ParseGradient("linear-gradient(#00000080, #fff)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !grad.IsTranslucent() {
+		t.Error("want translucent gradient")
+	}
+	og := grad.Opaque()
+	if og.IsTranslucent() {
+		t.Error("want opaque gradient")
+	}
+	if grad.Stops[0].Color.A != 0x80 {
+		t.Error("want original gradient to be unchanged")
+	}
+}
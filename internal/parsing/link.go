@@ -0,0 +1,42 @@
+package parsing
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A Link is a URL found in text at the byte offsets Start to End.
+type Link struct {
+	Start int
+	End   int
+	URL   string
+}
+
+// Only web links are matched, since clicking a link opens it: file:// links
+// in notification text could open or run local files.
+var linkPattern = regexp.MustCompile(`\bhttps?://[^\s<>"]+|\bwww\.[^\s<>"]+\.[^\s<>"]+`)
+
+// FindLinks returns the URLs in text. Punctuation at the end of a URL is
+// taken to belong to the surrounding sentence, as are closing parentheses
+// without an opening one in the URL.
+func FindLinks(text string) []Link {
+	var links []Link
+	for _, m := range linkPattern.FindAllStringIndex(text, -1) {
+		start, end := m[0], m[1]
+		for end > start {
+			last := text[end-1]
+			if strings.IndexByte(".,:;!?'", last) >= 0 ||
+				last == ')' && strings.Count(text[start:end], "(") < strings.Count(text[start:end], ")") {
+				end--
+				continue
+			}
+			break
+		}
+		url := text[start:end]
+		if strings.HasPrefix(url, "www.") {
+			url = "https://" + url
+		}
+		links = append(links, Link{Start: start, End: end, URL: url})
+	}
+	return links
+}
@@ -0,0 +1,41 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindLinks(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Link
+	}{
+		{"no links here", nil},
+		{
+			"Build failed: https://ci.example.com/builds/42.",
+			[]Link{{14, 46, "https://ci.example.com/builds/42"}},
+		},
+		{
+			"see (http://example.com/a_(b)) and www.example.org!",
+			[]Link{
+				{5, 29, "http://example.com/a_(b)"},
+				{35, 50, "https://www.example.org"},
+			},
+		},
+		{
+			"(https://example.com/x?y=1&z=2)",
+			[]Link{{1, 30, "https://example.com/x?y=1&z=2"}},
+		},
+		{`<a href="http://host/file">`, []Link{{9, 25, "http://host/file"}}},
+		{"ftp://host/file file:///etc/passwd", nil},
+		{"mailto:user@example.com www.", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := FindLinks(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package parsing
+
+import (
+	"strings"
+)
+
+// BlockKind is the kind of a block of Markdown.
+type BlockKind int
+
+const (
+	ParagraphBlock BlockKind = iota
+	ListItemBlock
+	CodeBlock
+)
+
+// A Block is a paragraph, an item of a bullet list or a fenced code block
+// of Markdown. Lines are kept as they are instead of being joined, like in
+// text that is not Markdown.
+type Block struct {
+	Kind BlockKind
+	// Level is the nesting depth of a list item, starting at 0.
+	Level int
+	// Lines of a code block hold a single span of code each.
+	Lines [][]Span
+}
+
+// A Span is a piece of a line that is text or inline code.
+type Span struct {
+	Text string
+	Code bool
+}
+
+// listIndent is the number of columns a nested list item is indented by.
+const listIndent = 2
+
+// ParseMarkdown parses the subset of Markdown that notifications use:
+// bullet lists starting with "-", "*" or "+", inline code in backticks and
+// code blocks fenced by ``` or ~~~. Everything else is text. Indented lines
+// after a list item continue the item.
+func ParseMarkdown(text string) []Block {
+	var (
+		blocks []Block
+		fence  string
+	)
+	last := func() *Block {
+		if len(blocks) == 0 {
+			return nil
+		}
+		return &blocks[len(blocks)-1]
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := columns(line[:len(line)-len(trimmed)])
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(trimmed[len(fence):]) == "" {
+				fence = ""
+				continue
+			}
+			b := last()
+			b.Lines = append(b.Lines, []Span{{Text: line, Code: true}})
+			continue
+		}
+		if f, ok := openingFence(trimmed); ok {
+			fence = f
+			blocks = append(blocks, Block{Kind: CodeBlock})
+			continue
+		}
+
+		if item, ok := listItem(trimmed); ok {
+			blocks = append(blocks, Block{
+				Kind:  ListItemBlock,
+				Level: indent / listIndent,
+				Lines: [][]Span{ParseSpans(item)},
+			})
+			continue
+		}
+		if b := last(); b != nil && b.Kind == ListItemBlock && indent > 0 && trimmed != "" {
+			b.Lines = append(b.Lines, ParseSpans(trimmed))
+			continue
+		}
+		if b := last(); b != nil && b.Kind == ParagraphBlock {
+			b.Lines = append(b.Lines, ParseSpans(line))
+			continue
+		}
+		blocks = append(blocks, Block{
+			Kind:  ParagraphBlock,
+			Lines: [][]Span{ParseSpans(line)},
+		})
+	}
+	return blocks
+}
+
+// columns returns the width of the indentation s with tabs of 4 columns.
+func columns(s string) int {
+	var n int
+	for _, r := range s {
+		if r == '\t' {
+			n += 4 - n%4
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// openingFence returns the fence that line opens, which is followed by an
+// optional info string like the language.
+func openingFence(line string) (string, bool) {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n < 3 {
+			continue
+		}
+		// Backticks in the info string would be inline code instead.
+		if c == "`" && strings.Contains(line[n:], "`") {
+			return "", false
+		}
+		return line[:n], true
+	}
+	return "", false
+}
+
+// listItem returns the text of the list item on line, if it is one.
+func listItem(line string) (string, bool) {
+	if len(line) < 2 || strings.IndexByte("-*+", line[0]) < 0 {
+		return "", false
+	}
+	if line[1] != ' ' && line[1] != '\t' {
+		return "", false
+	}
+	return strings.TrimLeft(line[2:], " \t"), true
+}
+
+// ParseSpans splits line into text and inline code. Code starts with a run
+// of backticks and ends with a run of the same length; a space on both
+// sides of the code is dropped, so that code can start with a backtick.
+// Backticks that are not closed are text.
+func ParseSpans(line string) []Span {
+	var (
+		spans []Span
+		text  strings.Builder
+	)
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			text.WriteByte(line[i])
+			i++
+			continue
+		}
+		n := len(line[i:]) - len(strings.TrimLeft(line[i:], "`"))
+		fence := line[i : i+n]
+		end := closingRun(line, i+n, fence)
+		if end < 0 {
+			text.WriteString(fence)
+			i += n
+			continue
+		}
+		if text.Len() > 0 {
+			spans = append(spans, Span{Text: text.String()})
+			text.Reset()
+		}
+		code := line[i+n : end]
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+		spans = append(spans, Span{Text: code, Code: true})
+		i = end + n
+	}
+	if text.Len() > 0 || len(spans) == 0 {
+		spans = append(spans, Span{Text: text.String()})
+	}
+	return spans
+}
+
+// closingRun returns the index of the first run of backticks in line from
+// start on that is exactly fence, or -1.
+func closingRun(line string, start int, fence string) int {
+	for i := start; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		n := len(line[i:]) - len(strings.TrimLeft(line[i:], "`"))
+		if n == len(fence) {
+			return i
+		}
+		i += n
+	}
+	return -1
+}
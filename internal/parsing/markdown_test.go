@@ -0,0 +1,96 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpans(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Span
+	}{
+		{"", []Span{{Text: ""}}},
+		{"plain text", []Span{{Text: "plain text"}}},
+		{
+			"run `make test` now",
+			[]Span{{Text: "run "}, {Text: "make test", Code: true}, {Text: " now"}},
+		},
+		{"``a ` b``", []Span{{Text: "a ` b", Code: true}}},
+		{"`` `x` ``", []Span{{Text: "`x`", Code: true}}},
+		{"not `closed", []Span{{Text: "not `closed"}}},
+		{"``not` closed", []Span{{Text: "``not` closed"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseSpans(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	text := func(s string) []Span { return []Span{{Text: s}} }
+	code := func(s string) []Span { return []Span{{Text: s, Code: true}} }
+	tests := []struct {
+		name  string
+		input string
+		want  []Block
+	}{
+		{
+			"paragraph",
+			"Release 1.2\nis out",
+			[]Block{{Kind: ParagraphBlock, Lines: [][]Span{text("Release 1.2"), text("is out")}}},
+		},
+		{
+			"list",
+			"Changes:\n- fix `-d 0`\n* nested?\n  - yes\n\tdeeper\n+ last",
+			[]Block{
+				{Kind: ParagraphBlock, Lines: [][]Span{text("Changes:")}},
+				{Kind: ListItemBlock, Lines: [][]Span{
+					{{Text: "fix "}, {Text: "-d 0", Code: true}},
+				}},
+				{Kind: ListItemBlock, Lines: [][]Span{text("nested?")}},
+				{Kind: ListItemBlock, Level: 1, Lines: [][]Span{text("yes"), text("deeper")}},
+				{Kind: ListItemBlock, Lines: [][]Span{text("last")}},
+			},
+		},
+		{
+			"not a list",
+			"-1 degrees\n*bold*",
+			[]Block{{Kind: ParagraphBlock, Lines: [][]Span{text("-1 degrees"), text("*bold*")}}},
+		},
+		{
+			"code block",
+			"Run:\n```sh\n$ make\n  `x`\n```\ndone",
+			[]Block{
+				{Kind: ParagraphBlock, Lines: [][]Span{text("Run:")}},
+				{Kind: CodeBlock, Lines: [][]Span{code("$ make"), code("  `x`")}},
+				{Kind: ParagraphBlock, Lines: [][]Span{text("done")}},
+			},
+		},
+		{
+			"unclosed code block",
+			"~~~~\n~~~\ncode",
+			[]Block{{Kind: CodeBlock, Lines: [][]Span{code("~~~"), code("code")}}},
+		},
+		{
+			"blank line ends list",
+			"- item\n\n  text",
+			[]Block{
+				{Kind: ListItemBlock, Lines: [][]Span{text("item")}},
+				{Kind: ParagraphBlock, Lines: [][]Span{text(""), text("  text")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMarkdown(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package parsing
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -31,3 +33,44 @@ func ParseNotification(input string) *Notification {
 	notif.Body = strings.TrimSpace(s.remaining())
 	return &notif
 }
+
+// String formats the notification as input to ParseNotification. The title
+// is always given in brackets, so that a body starting with a bracket is
+// not parsed as title.
+func (n *Notification) String() string {
+	return "[" + n.Title + "]" + n.Body
+}
+
+type Urgency int
+
+const (
+	UrgencyLow Urgency = iota
+	UrgencyNormal
+	UrgencyCritical
+)
+
+func (u Urgency) String() string {
+	switch u {
+	case UrgencyLow:
+		return "low"
+	case UrgencyCritical:
+		return "critical"
+	}
+	return "normal"
+}
+
+func ParseUrgency(input string) (Urgency, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "low":
+		return UrgencyLow, nil
+	case "normal":
+		return UrgencyNormal, nil
+	case "critical":
+		return UrgencyCritical, nil
+	}
+	return 0, fmt.Errorf(
+		"could not parse urgency %s: %w",
+		input,
+		errors.New("expected low, normal or critical"),
+	)
+}
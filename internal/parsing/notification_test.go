@@ -27,3 +27,40 @@ func TestParseNotification(t *testing.T) {
 		})
 	}
 }
+
+func TestNotification_String(t *testing.T) {
+	tests := []Notification{
+		{Title: "Title", Body: "Body"},
+		{Title: "", Body: "[x]Body"},
+		{Title: "Ti[]tle", Body: ""},
+		{},
+	}
+	for _, n := range tests {
+		if got := ParseNotification(n.String()); *got != n {
+			t.Errorf("ParseNotification(%q) = %v, want %v", n.String(), *got, n)
+		}
+	}
+}
+
+func TestParseUrgency(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Urgency
+	}{
+		{"low", UrgencyLow},
+		{"normal", UrgencyNormal},
+		{" Critical ", UrgencyCritical},
+	}
+	for _, tt := range tests {
+		got, err := ParseUrgency(tt.input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("got %v, want %v", got, tt.want)
+		}
+	}
+	if _, err := ParseUrgency("urgent"); err == nil {
+		t.Error("want error for invalid input")
+	}
+}
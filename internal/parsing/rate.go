@@ -0,0 +1,48 @@
+package parsing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Rate allows Count notifications per Period.
+type Rate struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseRate parses a rate as "<count>/<period>", e.g. "5/1m".
+func ParseRate(input string) (*Rate, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(input), "/")
+	if !ok {
+		return nil, fmt.Errorf(
+			"could not parse rate %s: %w",
+			input,
+			errors.New("expected <count>/<period>"),
+		)
+	}
+	var (
+		r   Rate
+		err error
+	)
+	r.Count, err = strconv.Atoi(count)
+	if err != nil || r.Count < 1 {
+		return nil, fmt.Errorf(
+			"could not parse count of rate %s: %w",
+			input,
+			errors.New("expected a positive integer"),
+		)
+	}
+	r.Period, err = time.ParseDuration(period)
+	if err != nil || r.Period <= 0 {
+		return nil, fmt.Errorf(
+			"could not parse period of rate %s: %w",
+			input,
+			errors.New("expected a positive duration"),
+		)
+	}
+	return &r, nil
+}
@@ -0,0 +1,33 @@
+package parsing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Rate
+		wantErr bool
+	}{
+		{"5/1m", Rate{Count: 5, Period: time.Minute}, false},
+		{" 10/30s ", Rate{Count: 10, Period: 30 * time.Second}, false},
+		{"5", Rate{}, true},
+		{"0/1m", Rate{}, true},
+		{"x/1m", Rate{}, true},
+		{"5/m", Rate{}, true},
+		{"5/-1m", Rate{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("got %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package parsing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Theme struct {
+	Background  string
+	Foreground  string
+	BorderColor string
+	Font        string
+}
+
+// Merge overrides the entries of t with the non-empty entries of other.
+func (t *Theme) Merge(other *Theme) {
+	if other.Background != "" {
+		t.Background = other.Background
+	}
+	if other.Foreground != "" {
+		t.Foreground = other.Foreground
+	}
+	if other.BorderColor != "" {
+		t.BorderColor = other.BorderColor
+	}
+	if other.Font != "" {
+		t.Font = other.Font
+	}
+}
+
+// ThemeFromResources maps the resources of application app onto a theme.
+// Without an explicit <app>.borderColor the border falls back to color1,
+// which is the accent color in pywal and base16 generated resources.
+func ThemeFromResources(res Resources, app string) *Theme {
+	var theme Theme
+	theme.Background, _ = res.Lookup(app, "background")
+	theme.Foreground, _ = res.Lookup(app, "foreground")
+	if v, ok := res.Lookup(app, "borderColor"); ok {
+		theme.BorderColor = v
+	} else {
+		theme.BorderColor, _ = res.Lookup(app, "color1")
+	}
+	for _, key := range []string{app + ".font", app + "*font"} {
+		if v, ok := res[key]; ok {
+			theme.Font = strings.TrimPrefix(v, "xft:")
+			break
+		}
+	}
+	return &theme
+}
+
+type pywalScheme struct {
+	Special struct {
+		Background string `json:"background"`
+		Foreground string `json:"foreground"`
+	} `json:"special"`
+	Colors map[string]string `json:"colors"`
+}
+
+// ParseJSONTheme parses a pywal colors.json or a base16 scheme in JSON
+// format. Base16 colors may be given with or without a leading '#'.
+func ParseJSONTheme(input []byte) (*Theme, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return nil, fmt.Errorf("could not parse theme: %w", err)
+	}
+
+	var theme Theme
+	if _, ok := fields["special"]; ok {
+		var scheme pywalScheme
+		if err := json.Unmarshal(input, &scheme); err != nil {
+			return nil, fmt.Errorf("could not parse pywal theme: %w", err)
+		}
+		theme.Background = scheme.Special.Background
+		theme.Foreground = scheme.Special.Foreground
+		theme.BorderColor = scheme.Colors["color1"]
+		return &theme, nil
+	}
+
+	var scheme map[string]interface{}
+	if err := json.Unmarshal(input, &scheme); err != nil {
+		return nil, fmt.Errorf("could not parse base16 theme: %w", err)
+	}
+	base16Color := func(name string) string {
+		v, ok := scheme[name].(string)
+		if !ok {
+			v, _ = scheme[strings.ToUpper(name)].(string)
+		}
+		if v != "" && !strings.HasPrefix(v, "#") {
+			v = "#" + v
+		}
+		return v
+	}
+	theme.Background = base16Color("base00")
+	theme.Foreground = base16Color("base05")
+	theme.BorderColor = base16Color("base08")
+	if theme.Background == "" && theme.Foreground == "" {
+		return nil, errors.New(
+			"could not parse theme: neither pywal nor base16 format",
+		)
+	}
+	return &theme, nil
+}
@@ -0,0 +1,91 @@
+package parsing
+
+import "testing"
+
+func TestThemeFromResources(t *testing.T) {
+	res := ParseXresources(`*.background: #1d2021
+*.foreground: #ebdbb2
+*.color1: #cc241d
+notify.foreground: #fbf1c7
+notify.font: xft:Fira Mono
+`)
+	want := Theme{
+		Background:  "#1d2021",
+		Foreground:  "#fbf1c7",
+		BorderColor: "#cc241d",
+		Font:        "Fira Mono",
+	}
+	got := ThemeFromResources(res, "notify")
+	if *got != want {
+		t.Errorf("got %v, want %v", *got, want)
+	}
+}
+
+func TestParseJSONTheme_ValidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Theme
+	}{
+		{
+			"pywal",
+			`{
+				"wallpaper": "/tmp/wall.png",
+				"special": {"background": "#0f1115", "foreground": "#c3c4c5", "cursor": "#c3c4c5"},
+				"colors": {"color0": "#0f1115", "color1": "#5A6B7A"}
+			}`,
+			Theme{
+				Background:  "#0f1115",
+				Foreground:  "#c3c4c5",
+				BorderColor: "#5A6B7A",
+			},
+		},
+		{
+			"base16",
+			`{"scheme": "Gruvbox", "base00": "282828", "base05": "d5c4a1", "base08": "#fb4934"}`,
+			Theme{
+				Background:  "#282828",
+				Foreground:  "#d5c4a1",
+				BorderColor: "#fb4934",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSONTheme([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONTheme_InvalidInput(t *testing.T) {
+	testInputs := []string{
+		"",
+		"{",
+		"[]",
+		`{"name": "neither"}`,
+	}
+	for _, ti := range testInputs {
+		testname := ti
+		t.Run(testname, func(t *testing.T) {
+			_, err := ParseJSONTheme([]byte(ti))
+			if err == nil {
+				t.Error("want error for invalid input")
+			}
+		})
+	}
+}
+
+func TestThemeMerge(t *testing.T) {
+	theme := Theme{Background: "#000", Foreground: "#fff"}
+	theme.Merge(&Theme{Foreground: "#eee", Font: "Inconsolata"})
+	want := Theme{Background: "#000", Foreground: "#eee", Font: "Inconsolata"}
+	if theme != want {
+		t.Errorf("got %v, want %v", theme, want)
+	}
+}
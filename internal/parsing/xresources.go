@@ -0,0 +1,42 @@
+package parsing
+
+import (
+	"strings"
+)
+
+type Resources map[string]string
+
+func ParseXresources(input string) Resources {
+	res := make(Resources)
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "!") ||
+			strings.HasPrefix(line, "#") {
+			continue
+		}
+		s := newState(line)
+		key, s, err := lexUntil(s, ':')
+		if err != nil {
+			continue
+		}
+		key = strings.Join(strings.Fields(key), "")
+		res[key] = strings.TrimSpace(s.remaining())
+	}
+	return res
+}
+
+// Lookup returns the value of resource name for the application app,
+// preferring application specific entries over loosely bound ones.
+func (res Resources) Lookup(app, name string) (string, bool) {
+	for _, key := range []string{
+		app + "." + name,
+		app + "*" + name,
+		"*." + name,
+		"*" + name,
+	} {
+		if v, ok := res[key]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
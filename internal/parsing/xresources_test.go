@@ -0,0 +1,42 @@
+package parsing
+
+import "testing"
+
+func TestParseXresources(t *testing.T) {
+	input := `! comment
+#define FOO bar
+*.background:	#1d2021
+*foreground: #ebdbb2
+*.color1:	rgb:cc/24/1d
+notify.background:	#282828
+URxvt.font:	xft:Inconsolata:size=12
+  notify . font :  Fira Mono
+invalid line
+`
+	res := ParseXresources(input)
+
+	tests := []struct {
+		app, name string
+		want      string
+		wantOk    bool
+	}{
+		{"notify", "background", "#282828", true},
+		{"other", "background", "#1d2021", true},
+		{"notify", "foreground", "#ebdbb2", true},
+		{"notify", "color1", "rgb:cc/24/1d", true},
+		{"notify", "font", "Fira Mono", true},
+		{"notify", "borderColor", "", false},
+	}
+	for _, tt := range tests {
+		testname := tt.app + "." + tt.name
+		t.Run(testname, func(t *testing.T) {
+			got, ok := res.Lookup(tt.app, tt.name)
+			if ok != tt.wantOk {
+				t.Fatalf("got ok %v, want %v", ok, tt.wantOk)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package pixel
+
+import (
+	"image/color"
+	"log"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/raster"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/faiface/mainthread"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// WindowBackend displays notifications in an OpenGL window.
+type WindowBackend struct{}
+
+func (WindowBackend) Show(
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	var (
+		ev  render.Event
+		err error
+	)
+	pixelgl.Run(func() {
+		ev, err = showWindow(l, opts)
+	})
+	return ev, err
+}
+
+func showWindow(l *layout.Layout, opts render.Options) (render.Event, error) {
+	transparent := l.IsTranslucent()
+	win, err := SetupWindow(
+		opts.Title,
+		l.Width,
+		l.Height,
+		opts.X,
+		opts.Y,
+		transparent,
+		opts.ClickThrough,
+		opts.Sticky,
+	)
+	if err != nil {
+		return render.EventNone, err
+	}
+	// The clipboard is owned by the window, so that copied text can only
+	// be pasted while the notification is shown, unless a clipboard
+	// manager takes it over.
+	glfwWin := currentGLFWWindow()
+	// Updates may turn translucent, so that the framebuffer is checked
+	// regardless of the first layout.
+	opaque := !FramebufferTransparent()
+	if transparent && opaque {
+		log.Print(
+			"warning: transparency is not supported (is a compositor running?), " +
+				"falling back to opaque colors",
+		)
+	}
+	draw := func(l *layout.Layout) {
+		if opaque {
+			l = l.Opaque()
+		}
+		win.Clear(color.Transparent)
+		win.SetColorMask(pixel.Alpha(l.Opacity))
+		raster.Draw(win, l)
+	}
+	draw(l)
+
+	closeWin := render.Timeout(opts.Duration)
+	var restore <-chan time.Time
+	for !win.Closed() {
+		if !opts.ClickThrough {
+			if win.JustPressed(pixelgl.MouseButtonLeft) &&
+				!render.ClickLink(l, opts, win.MousePosition()) {
+				return render.EventLeftClick, nil
+			}
+			if win.JustPressed(pixelgl.MouseButtonRight) {
+				return render.EventRightClick, nil
+			}
+			if win.JustPressed(pixelgl.MouseButtonMiddle) {
+				if opts.Copy == nil {
+					return render.EventMiddleClick, nil
+				}
+				text := opts.Copy()
+				mainthread.Call(func() {
+					glfwWin.SetClipboardString(text)
+				})
+				draw(l.Inverted())
+				restore = time.After(render.FeedbackDuration)
+			}
+		}
+		select {
+		case <-restore:
+			draw(l)
+		case <-closeWin:
+			return render.EventTimeout, nil
+		case <-opts.Cancel:
+			return render.EventCanceled, nil
+		case n := <-opts.Updates:
+			nl := opts.Relayout(n)
+			if nl.Width != l.Width || nl.Height != l.Height {
+				ResizeWindow(win, nl.Width, nl.Height, opts.X, opts.Y)
+			}
+			l = nl
+			draw(l)
+			closeWin = render.Timeout(opts.Duration)
+		default:
+			win.Update()
+		}
+	}
+	return render.EventClosed, nil
+}
@@ -1,56 +1,124 @@
 package pixel
 
 import (
-	"image/color"
+	"log"
 
+	"github.com/LinusMB/Notify/internal/ewmh"
+	"github.com/faiface/mainthread"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/imdraw"
 	"github.com/faiface/pixel/pixelgl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
 )
 
 func SetupWindow(
 	title string,
 	winWidth, winHeight, winX, winY float64,
+	transparent, clickThrough, sticky bool,
 ) (*pixelgl.Window, error) {
-	winBox := createBox(winWidth, winHeight, pixel.ZV)
-	monW, monH := pixelgl.PrimaryMonitor().Size()
-	position := pixel.V(winX, winY)
-	if winX < 0 {
-		position.X = monW + winX - winWidth
-	}
-	if winY < 0 {
-		position.Y = monH + winY - winHeight
-	}
+	winBox := pixel.R(0, 0, winWidth, winHeight)
+	position := windowPosition(winWidth, winHeight, winX, winY)
+	// pixelgl leaves the hints it does not know about untouched.
+	mainthread.Call(func() {
+		glfw.WindowHintString(glfw.X11InstanceName, title)
+		glfw.WindowHintString(glfw.X11ClassName, ewmh.Class(title))
+		glfw.WindowHint(glfw.FocusOnShow, glfw.False)
+	})
+	// The window is created invisible and positioned by hand, so that the
+	// window hints are in place before it is mapped.
 	cfg := pixelgl.WindowConfig{
 		Title:       title,
 		Bounds:      winBox,
-		Position:    position,
 		VSync:       true,
 		Undecorated: true,
+		AlwaysOnTop: true,
+		Invisible:   true,
+
+		TransparentFramebuffer: transparent,
 	}
 	win, err := pixelgl.NewWindow(cfg)
 	if err != nil {
 		return nil, err
 	}
 	win.SetSmooth(true)
+
+	w := currentGLFWWindow()
+	if err := setWindowProperties(w, title, clickThrough, sticky); err != nil {
+		log.Printf("warning: could not set window properties: %v", err)
+	}
+	mainthread.Call(func() {
+		w.SetPos(int(position.X), int(position.Y))
+		w.Show()
+	})
 	return win, nil
 }
 
-func fillBox(
-	imd *imdraw.IMDraw,
-	r pixel.Rect,
-	c color.Color,
-) {
-	imd.Color = c
-	vs := r.Vertices()
-	imd.Push(vs[0], vs[1], vs[2], vs[3])
-	imd.Polygon(0)
+// windowPosition returns the top left corner of a window, where negative
+// coordinates are relative to the bottom right corner of the screen.
+func windowPosition(winWidth, winHeight, winX, winY float64) pixel.Vec {
+	monW, monH := pixelgl.PrimaryMonitor().Size()
+	position := pixel.V(winX, winY)
+	if winX < 0 {
+		position.X = monW + winX - winWidth
+	}
+	if winY < 0 {
+		position.Y = monH + winY - winHeight
+	}
+	return position
+}
+
+// ResizeWindow resizes the window and moves it, so that it keeps its
+// position relative to the corner of the screen it is placed at.
+func ResizeWindow(win *pixelgl.Window, winWidth, winHeight, winX, winY float64) {
+	win.SetBounds(pixel.R(0, 0, winWidth, winHeight))
+	win.SetPos(windowPosition(winWidth, winHeight, winX, winY))
+}
+
+func setWindowProperties(
+	w *glfw.Window,
+	title string,
+	clickThrough, sticky bool,
+) error {
+	var id xproto.Window
+	mainthread.Call(func() {
+		id = xproto.Window(uint64(w.GetX11Window()))
+	})
+	c, err := xgb.NewConn()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := ewmh.SetNotificationHints(c, id, title, sticky); err != nil {
+		return err
+	}
+	if clickThrough {
+		return ewmh.SetClickThrough(c, id)
+	}
+	return nil
 }
 
-func createBox(width, height float64, center pixel.Vec) pixel.Rect {
-	return centerBox(pixel.R(0, 0, width, height), center)
+// currentGLFWWindow returns the GLFW window of the most recently set up
+// window, whose context pixelgl leaves current on the main thread.
+func currentGLFWWindow() *glfw.Window {
+	var w *glfw.Window
+	mainthread.Call(func() {
+		w = glfw.GetCurrentContext()
+	})
+	return w
 }
 
-func centerBox(box pixel.Rect, center pixel.Vec) pixel.Rect {
-	return box.Moved(box.Center().To(center))
+// FramebufferTransparent reports whether the framebuffer of the window is
+// composited with the background, which on X11 requires both a transparent
+// visual and a running compositor.
+func FramebufferTransparent() bool {
+	w := currentGLFWWindow()
+	if w == nil {
+		return false
+	}
+	var transparent bool
+	mainthread.Call(func() {
+		transparent = w.GetAttrib(glfw.TransparentFramebuffer) == glfw.True
+	})
+	return transparent
 }
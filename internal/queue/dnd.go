@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/lock"
+)
+
+// A Deferred notification was held back while do-not-disturb was on. It is
+// replayed by running the program again with Args in Dir and Input on
+// stdin.
+type Deferred struct {
+	Args  []string  `json:"args"`
+	Dir   string    `json:"dir"`
+	Input string    `json:"input"`
+	Since time.Time `json:"since"`
+
+	path string
+}
+
+// StateDir returns the default directory of the deferred notifications.
+// Without a home directory it is the directory of the lock files, where
+// they do not survive a reboot.
+func StateDir(app string) string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, app)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return lock.Dir(app)
+	}
+	return filepath.Join(home, ".local", "state", app)
+}
+
+func dndPath(dir string) string {
+	return filepath.Join(dir, "dnd")
+}
+
+func deferredDir(dir string) string {
+	return filepath.Join(dir, "deferred")
+}
+
+func EnableDND(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("could not create queue directory: %w", err)
+	}
+	file, err := os.Create(dndPath(dir))
+	if err != nil {
+		return fmt.Errorf("could not enable do-not-disturb: %w", err)
+	}
+	return file.Close()
+}
+
+// DisableDND turns do-not-disturb off. The deferred notifications are kept
+// until they are replayed and removed.
+func DisableDND(dir string) error {
+	err := os.Remove(dndPath(dir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not disable do-not-disturb: %w", err)
+	}
+	return nil
+}
+
+func DNDEnabled(dir string) bool {
+	_, err := os.Stat(dndPath(dir))
+	return err == nil
+}
+
+// Defer stores a notification in dir until do-not-disturb is turned off.
+// Unlike the do-not-disturb state, dir should outlive a reboot, so that
+// deferred notifications are not lost.
+func Defer(dir string, d Deferred) error {
+	if err := os.MkdirAll(deferredDir(dir), 0o700); err != nil {
+		return fmt.Errorf("could not create queue directory: %w", err)
+	}
+	d.Since = time.Now()
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	// The file is renamed into place, so that DisableDND does not read it
+	// half written. The names sort in the order the notifications came in.
+	name := fmt.Sprintf("%020d-%d.json", d.Since.UnixNano(), os.Getpid())
+	path := filepath.Join(deferredDir(dir), name)
+	if err := os.WriteFile(path+".tmp", b, 0o600); err != nil {
+		return fmt.Errorf("could not defer notification: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("could not defer notification: %w", err)
+	}
+	return nil
+}
+
+// ListDeferred returns the deferred notifications in dir, oldest first.
+func ListDeferred(dir string) ([]Deferred, error) {
+	paths, err := filepath.Glob(filepath.Join(deferredDir(dir), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	deferred := make([]Deferred, 0, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read deferred notification: %w", err)
+		}
+		d := Deferred{path: path}
+		if err := json.Unmarshal(b, &d); err != nil {
+			return nil, fmt.Errorf(
+				"could not parse deferred notification %s: %w",
+				filepath.Base(path),
+				err,
+			)
+		}
+		deferred = append(deferred, d)
+	}
+	return deferred, nil
+}
+
+// Remove removes the deferred notification once it has been replayed.
+func (d *Deferred) Remove() error {
+	if err := os.Remove(d.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove deferred notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDND(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run")
+	stateDir := filepath.Join(t.TempDir(), "state")
+	if DNDEnabled(dir) {
+		t.Fatal("do-not-disturb is on by default")
+	}
+	if err := EnableDND(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !DNDEnabled(dir) {
+		t.Error("do-not-disturb is off after EnableDND")
+	}
+
+	inputs := []string{"[Mail]first", "[Mail]second", "third"}
+	for _, input := range inputs {
+		if err := Defer(stateDir, Deferred{Args: []string{"-d", "1s"}, Input: input}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listed, err := ListDeferred(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(inputs) {
+		t.Errorf("got %d deferred notifications, want %d", len(listed), len(inputs))
+	}
+
+	if err := DisableDND(dir); err != nil {
+		t.Fatal(err)
+	}
+	if DNDEnabled(dir) {
+		t.Error("do-not-disturb is on after DisableDND")
+	}
+	deferred, err := ListDeferred(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range deferred {
+		got = append(got, d.Input)
+		if !reflect.DeepEqual(d.Args, []string{"-d", "1s"}) {
+			t.Errorf("got args %q, want [-d 1s]", d.Args)
+		}
+	}
+	if !reflect.DeepEqual(got, inputs) {
+		t.Errorf("got deferred inputs %q, want %q", got, inputs)
+	}
+
+	// Notifications that are not replayed are kept.
+	if err := deferred[0].Remove(); err != nil {
+		t.Fatal(err)
+	}
+	deferred, err = ListDeferred(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deferred) != len(inputs)-1 {
+		t.Errorf("got %d deferred notifications after replay, want %d", len(deferred), len(inputs)-1)
+	}
+	for _, d := range deferred {
+		if err := d.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deferred, err = ListDeferred(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deferred) != 0 {
+		t.Errorf("got %d deferred notifications after replay, want 0", len(deferred))
+	}
+}
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/home/user/.state")
+	if got := StateDir("notify"); got != "/home/user/.state/notify" {
+		t.Errorf("got %q, want /home/user/.state/notify", got)
+	}
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/user")
+	if got := StateDir("notify"); got != "/home/user/.local/state/notify" {
+		t.Errorf("got %q, want /home/user/.local/state/notify", got)
+	}
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := StateDir("notify"); got != "/run/user/1000/notify" {
+		t.Errorf("got %q without a home directory, want the lock directory", got)
+	}
+}
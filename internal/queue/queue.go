@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Status is the state of a notification process.
+type Status string
+
+const (
+	// Waiting notifications wait for the lock of their channel.
+	Waiting Status = "waiting"
+	// Paused notifications hold the lock but wait for the queue to be
+	// resumed.
+	Paused Status = "paused"
+	Shown  Status = "shown"
+)
+
+// An Entry describes a notification process in the queue directory.
+type Entry struct {
+	Pid     int    `json:"pid"`
+	Channel string `json:"channel"`
+	ID      string `json:"id,omitempty"`
+	Text    string `json:"text"`
+	Status  Status `json:"status"`
+	// Since is when the notification was queued.
+	Since time.Time `json:"since"`
+}
+
+func entriesDir(dir string) string {
+	return filepath.Join(dir, "queue")
+}
+
+func pausedPath(dir string) string {
+	return filepath.Join(dir, "paused")
+}
+
+// A Registration is the entry of the running process. The process holds a
+// lock on the entry file for as long as it runs, so that entries of
+// processes that crashed can be told apart by their file not being locked.
+type Registration struct {
+	file  *os.File
+	path  string
+	entry Entry
+}
+
+// Register adds the entry of the running process to the queue directory in
+// dir.
+func Register(dir string, e Entry) (*Registration, error) {
+	e.Since = time.Now()
+	if err := os.MkdirAll(entriesDir(dir), 0o700); err != nil {
+		return nil, fmt.Errorf("could not create queue directory: %w", err)
+	}
+	path := filepath.Join(entriesDir(dir), strconv.Itoa(e.Pid)+".json")
+	// The entry is locked under a temporary name first, as List would
+	// remove it as stale otherwise.
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not create queue entry: %w", err)
+	}
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not lock queue entry: %w", err)
+	}
+	r := Registration{file: file, path: path + ".tmp", entry: e}
+	if err := r.write(); err != nil {
+		r.Remove()
+		return nil, err
+	}
+	if err := os.Rename(r.path, path); err != nil {
+		r.Remove()
+		return nil, fmt.Errorf("could not create queue entry: %w", err)
+	}
+	r.path = path
+	return &r, nil
+}
+
+func (r *Registration) write() error {
+	b, err := json.Marshal(r.entry)
+	if err != nil {
+		return err
+	}
+	if err := r.file.Truncate(0); err != nil {
+		return fmt.Errorf("could not write queue entry: %w", err)
+	}
+	if _, err := r.file.WriteAt(b, 0); err != nil {
+		return fmt.Errorf("could not write queue entry: %w", err)
+	}
+	return nil
+}
+
+func (r *Registration) SetStatus(s Status) error {
+	r.entry.Status = s
+	return r.write()
+}
+
+// SetText records the text of a notification that replaced the one the
+// entry was registered with.
+func (r *Registration) SetText(text string) error {
+	r.entry.Text = text
+	return r.write()
+}
+
+// Remove removes the entry from the queue directory.
+func (r *Registration) Remove() error {
+	defer r.file.Close()
+	return os.Remove(r.path)
+}
+
+// List returns the entries in the queue directory in dir, oldest first.
+// Entries of processes that are not running anymore are removed.
+func List(dir string) ([]Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(entriesDir(dir), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, path := range paths {
+		e, ok, err := readEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Since.Before(entries[j].Since)
+	})
+	return entries, nil
+}
+
+// readEntry reads the entry at path. Stale entries are removed and
+// reported as not ok, as are entries that are being written.
+func readEntry(path string) (Entry, bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("could not read queue entry: %w", err)
+	}
+	defer file.Close()
+
+	err = unix.Flock(int(file.Fd()), unix.LOCK_SH|unix.LOCK_NB)
+	if err == nil {
+		os.Remove(path)
+		return Entry{}, false, nil
+	}
+	if err != unix.EWOULDBLOCK {
+		return Entry{}, false, fmt.Errorf("could not lock queue entry: %w", err)
+	}
+
+	var e Entry
+	if err := json.NewDecoder(file).Decode(&e); err != nil {
+		return Entry{}, false, nil
+	}
+	return e, true, nil
+}
+
+// Pause pauses the queue: notifications that acquire the lock of their
+// channel are not shown until Resume is called.
+func Pause(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("could not create queue directory: %w", err)
+	}
+	file, err := os.Create(pausedPath(dir))
+	if err != nil {
+		return fmt.Errorf("could not pause queue: %w", err)
+	}
+	return file.Close()
+}
+
+func Resume(dir string) error {
+	err := os.Remove(pausedPath(dir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not resume queue: %w", err)
+	}
+	return nil
+}
+
+func IsPaused(dir string) bool {
+	_, err := os.Stat(pausedPath(dir))
+	return err == nil
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Register(dir, Entry{Pid: 10, Channel: "default", Text: "first", Status: Waiting})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Register(dir, Entry{Pid: 20, Channel: "volume", ID: "volume", Text: "second", Status: Waiting})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SetStatus(Shown); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.SetText("second (×2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A process that crashed leaves its entry unlocked.
+	stale := filepath.Join(entriesDir(dir), "30.json")
+	if err := os.WriteFile(stale, []byte(`{"pid":30,"status":"shown"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %v", len(entries), entries)
+	}
+	if e := entries[0]; e.Pid != 10 || e.Text != "first" || e.Status != Shown {
+		t.Errorf("got first entry %+v, want the shown entry of pid 10", e)
+	}
+	if e := entries[1]; e.Pid != 20 || e.ID != "volume" || e.Text != "second (×2)" || e.Status != Waiting {
+		t.Errorf("got second entry %+v, want the updated waiting entry of pid 20", e)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale entry was not removed: %v", err)
+	}
+
+	first.Remove()
+	second.Remove()
+	entries, err = List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries after removal, want 0", len(entries))
+	}
+}
+
+func TestPause(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "notify")
+	if IsPaused(dir) {
+		t.Fatal("new queue is paused")
+	}
+	if err := Pause(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !IsPaused(dir) {
+		t.Error("queue is not paused after Pause")
+	}
+	if err := Resume(dir); err != nil {
+		t.Fatal(err)
+	}
+	if IsPaused(dir) {
+		t.Error("queue is paused after Resume")
+	}
+	if err := Resume(dir); err != nil {
+		t.Errorf("resuming a running queue: %v", err)
+	}
+}
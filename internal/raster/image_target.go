@@ -0,0 +1,257 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/faiface/pixel"
+)
+
+// ImageTarget is a pixel.Target that rasterizes triangles in software into
+// an image, so that notifications can be rendered without a display or GPU.
+// It mirrors pixelgl's canvas: colors are premultiplied, triangles are
+// composited with pixel.ComposeOver and pictures are sampled smoothly.
+type ImageTarget struct {
+	bounds pixel.Rect
+	img    *image.RGBA
+	mask   pixel.RGBA
+}
+
+// NewImageTarget creates an image target with the same coordinate system as
+// a pixelgl window of the size.
+func NewImageTarget(winWidth, winHeight float64) *ImageTarget {
+	bounds := pixel.R(0, 0, winWidth, winHeight)
+	it := ImageTarget{
+		bounds: bounds,
+		img: image.NewRGBA(image.Rect(
+			0,
+			0,
+			int(math.Round(bounds.W())),
+			int(math.Round(bounds.H())),
+		)),
+		mask: pixel.Alpha(1),
+	}
+	return &it
+}
+
+func (it *ImageTarget) Image() *image.RGBA {
+	return it.img
+}
+
+func (it *ImageTarget) SetColorMask(c color.Color) {
+	if c == nil {
+		c = pixel.Alpha(1)
+	}
+	it.mask = pixel.ToRGBA(c)
+}
+
+func (it *ImageTarget) Clear(c color.Color) {
+	rgba := pixel.ToRGBA(c)
+	clr := color.RGBA{
+		R: uint8(math.Round(rgba.R * 0xff)),
+		G: uint8(math.Round(rgba.G * 0xff)),
+		B: uint8(math.Round(rgba.B * 0xff)),
+		A: uint8(math.Round(rgba.A * 0xff)),
+	}
+	b := it.img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			it.img.SetRGBA(x, y, clr)
+		}
+	}
+}
+
+func (it *ImageTarget) MakeTriangles(t pixel.Triangles) pixel.TargetTriangles {
+	tri := &imageTriangles{
+		TrianglesData: pixel.MakeTrianglesData(t.Len()),
+		dst:           it,
+	}
+	tri.Update(t)
+	return tri
+}
+
+func (it *ImageTarget) MakePicture(p pixel.Picture) pixel.TargetPicture {
+	return &imagePicture{
+		PictureData: pixel.PictureDataFromPicture(p),
+		dst:         it,
+	}
+}
+
+// SavePNG encodes the rendered image as PNG to the file at path.
+func (it *ImageTarget) SavePNG(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, it.img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+type imageTriangles struct {
+	*pixel.TrianglesData
+	dst *ImageTarget
+}
+
+func (tri *imageTriangles) Slice(i, j int) pixel.Triangles {
+	return &imageTriangles{
+		TrianglesData: tri.TrianglesData.Slice(i, j).(*pixel.TrianglesData),
+		dst:           tri.dst,
+	}
+}
+
+func (tri *imageTriangles) Copy() pixel.Triangles {
+	return &imageTriangles{
+		TrianglesData: tri.TrianglesData.Copy().(*pixel.TrianglesData),
+		dst:           tri.dst,
+	}
+}
+
+func (tri *imageTriangles) Draw() {
+	tri.dst.rasterize(tri.TrianglesData, nil)
+}
+
+type imagePicture struct {
+	*pixel.PictureData
+	dst *ImageTarget
+}
+
+func (pic *imagePicture) Draw(t pixel.TargetTriangles) {
+	tri, ok := t.(*imageTriangles)
+	if !ok {
+		panic("(*imagePicture).Draw: TargetTriangles generated by different Target")
+	}
+	pic.dst.rasterize(tri.TrianglesData, pic.PictureData)
+}
+
+func (it *ImageTarget) rasterize(td *pixel.TrianglesData, pd *pixel.PictureData) {
+	for i := 0; i+2 < len(*td); i += 3 {
+		it.rasterizeTriangle(td, i, pd)
+	}
+}
+
+// toImage converts a position in target coordinates (origin in the bottom
+// left corner, y pointing upwards) to image coordinates.
+func (it *ImageTarget) toImage(v pixel.Vec) pixel.Vec {
+	return pixel.V(v.X-it.bounds.Min.X, it.bounds.Max.Y-v.Y)
+}
+
+func (it *ImageTarget) rasterizeTriangle(
+	td *pixel.TrianglesData,
+	i int,
+	pd *pixel.PictureData,
+) {
+	vs := (*td)[i : i+3]
+	p := [3]pixel.Vec{
+		it.toImage(vs[0].Position),
+		it.toImage(vs[1].Position),
+		it.toImage(vs[2].Position),
+	}
+	area := edge(p[0], p[1], p[2])
+	if area == 0 {
+		return
+	}
+
+	b := it.img.Bounds()
+	minX := int(math.Max(math.Floor(math.Min(p[0].X, math.Min(p[1].X, p[2].X))), float64(b.Min.X)))
+	maxX := int(math.Min(math.Ceil(math.Max(p[0].X, math.Max(p[1].X, p[2].X))), float64(b.Max.X)))
+	minY := int(math.Max(math.Floor(math.Min(p[0].Y, math.Min(p[1].Y, p[2].Y))), float64(b.Min.Y)))
+	maxY := int(math.Min(math.Ceil(math.Max(p[0].Y, math.Max(p[1].Y, p[2].Y))), float64(b.Max.Y)))
+
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			c := pixel.V(float64(x)+0.5, float64(y)+0.5)
+			w := [3]float64{
+				edge(p[1], p[2], c) / area,
+				edge(p[2], p[0], c) / area,
+				edge(p[0], p[1], c) / area,
+			}
+			if !covers(w, p, area) {
+				continue
+			}
+
+			var (
+				clr       pixel.RGBA
+				tex       pixel.Vec
+				intensity float64
+			)
+			for k := range w {
+				clr = clr.Add(vs[k].Color.Scaled(w[k]))
+				tex = tex.Add(vs[k].Picture.Scaled(w[k]))
+				intensity += vs[k].Intensity * w[k]
+			}
+			if pd != nil && intensity != 0 {
+				texel := sampleBilinear(pd, tex)
+				clr = clr.Scaled(1 - intensity).Add(clr.Mul(texel).Scaled(intensity))
+			}
+			clr = clr.Mul(it.mask)
+			it.blend(x, y, clr)
+		}
+	}
+}
+
+func (it *ImageTarget) blend(x, y int, src pixel.RGBA) {
+	dst := it.img.RGBAAt(x, y)
+	blendChannel := func(s float64, d uint8) uint8 {
+		v := s*0xff + float64(d)*(1-src.A)
+		return uint8(math.Round(math.Max(0, math.Min(0xff, v))))
+	}
+	it.img.SetRGBA(x, y, color.RGBA{
+		R: blendChannel(src.R, dst.R),
+		G: blendChannel(src.G, dst.G),
+		B: blendChannel(src.B, dst.B),
+		A: blendChannel(src.A, dst.A),
+	})
+}
+
+func edge(a, b, c pixel.Vec) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// covers reports whether a pixel with barycentric coordinates w lies inside
+// the triangle p. Pixels exactly on an edge are only covered by one of two
+// adjacent triangles (top-left rule), so that shared edges of translucent
+// polygons are not blended twice.
+func covers(w [3]float64, p [3]pixel.Vec, area float64) bool {
+	for k := range w {
+		if w[k] < 0 {
+			return false
+		}
+		if w[k] == 0 {
+			a, b := p[(k+1)%3], p[(k+2)%3]
+			if area < 0 {
+				a, b = b, a
+			}
+			topLeft := (a.Y == b.Y && b.X < a.X) || b.Y < a.Y
+			if !topLeft {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sampleBilinear(pd *pixel.PictureData, at pixel.Vec) pixel.RGBA {
+	r := pd.Rect
+	clampX := func(x float64) float64 {
+		return math.Max(r.Min.X, math.Min(r.Max.X-1, x))
+	}
+	clampY := func(y float64) float64 {
+		return math.Max(r.Min.Y, math.Min(r.Max.Y-1, y))
+	}
+	x, y := at.X-0.5, at.Y-0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+
+	texel := func(x, y float64) pixel.RGBA {
+		return pd.Color(pixel.V(clampX(x), clampY(y)))
+	}
+	top := texel(x0, y0).Scaled(1 - fx).Add(texel(x0+1, y0).Scaled(fx))
+	bottom := texel(x0, y0+1).Scaled(1 - fx).Add(texel(x0+1, y0+1).Scaled(fx))
+	return top.Scaled(1 - fy).Add(bottom.Scaled(fy))
+}
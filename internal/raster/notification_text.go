@@ -0,0 +1,54 @@
+package raster
+
+import (
+	"fmt"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/font"
+)
+
+type NotificationText struct {
+	lines      []*text.Text
+	underlines *imdraw.IMDraw
+}
+
+func (nt *NotificationText) Draw(t pixel.Target) {
+	for _, line := range nt.lines {
+		line.Draw(t, pixel.IM)
+	}
+	nt.underlines.Draw(t)
+}
+
+func SetupNotificationText(runs []layout.TextRun) *NotificationText {
+	nt := NotificationText{
+		underlines: imdraw.New(nil),
+	}
+	atlases := make(map[font.Face]*text.Atlas)
+	for _, run := range runs {
+		atlas, ok := atlases[run.Face]
+		if !ok {
+			atlas = text.NewAtlas(run.Face, text.ASCII, []rune{layout.Bullet})
+			atlases[run.Face] = atlas
+		}
+		line := text.New(run.Dot, atlas)
+		// Links are written in their color in between, so that the dot
+		// advances over the whole line like without links.
+		runes := []rune(run.Text)
+		var written int
+		for _, link := range run.Links {
+			line.Color = run.Color
+			fmt.Fprint(line, string(runes[written:link.Start]))
+			line.Color = link.Color
+			fmt.Fprint(line, string(runes[link.Start:link.End]))
+			written = link.End
+			fillBox(nt.underlines, link.Underline, link.Color)
+		}
+		line.Color = run.Color
+		fmt.Fprint(line, string(runes[written:]))
+		nt.lines = append(nt.lines, line)
+	}
+	return &nt
+}
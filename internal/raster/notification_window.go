@@ -0,0 +1,102 @@
+package raster
+
+import (
+	"math"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// gradientStep is the edge length in pixels of the cells of the mesh a
+// gradient is rendered with.
+const gradientStep = 4
+
+type placedSprite struct {
+	sprite *pixel.Sprite
+	mat    pixel.Matrix
+}
+
+type NotificationWindow struct {
+	imd     *imdraw.IMDraw
+	sprites []placedSprite
+	// blocks are drawn over the background image.
+	blocks *imdraw.IMDraw
+}
+
+func (nw *NotificationWindow) Draw(t pixel.Target) {
+	nw.imd.Draw(t)
+	for _, ps := range nw.sprites {
+		ps.sprite.Draw(t, ps.mat)
+	}
+	nw.blocks.Draw(t)
+}
+
+func SetupNotificationWindow(l *layout.Layout) *NotificationWindow {
+	imd := imdraw.New(nil)
+	for _, b := range l.Border {
+		fillBox(imd, b.Rect, b.Color)
+	}
+	if l.Content.Gradient != nil {
+		fillGradient(imd, l.Content)
+	} else if l.Content.Color != nil {
+		fillBox(imd, l.Content.Rect, l.Content.Color)
+	}
+	blocks := imdraw.New(nil)
+	for _, b := range l.Blocks {
+		fillBox(blocks, b.Rect, b.Color)
+	}
+	nw := NotificationWindow{
+		imd:    imd,
+		blocks: blocks,
+	}
+	if l.Image != nil {
+		pic := pixel.PictureDataFromImage(l.Image)
+		for _, p := range l.Placements {
+			nw.sprites = append(nw.sprites, placeSprite(pic, p))
+		}
+	}
+	return &nw
+}
+
+func fillGradient(imd *imdraw.IMDraw, b layout.Box) {
+	r := b.Rect
+	cols := int(math.Ceil(r.W() / gradientStep))
+	rows := int(math.Ceil(r.H() / gradientStep))
+	vertex := func(col, row int) pixel.Vec {
+		return pixel.V(
+			math.Min(r.Min.X+float64(col)*gradientStep, r.Max.X),
+			math.Min(r.Min.Y+float64(row)*gradientStep, r.Max.Y),
+		)
+	}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			for _, v := range []pixel.Vec{
+				vertex(col, row),
+				vertex(col+1, row),
+				vertex(col+1, row+1),
+				vertex(col, row+1),
+			} {
+				imd.Color = pixel.ToRGBA(b.ColorAt(v))
+				imd.Push(v)
+			}
+			imd.Polygon(0)
+		}
+	}
+}
+
+// placeSprite maps the placement's source region, given in image
+// coordinates, to the vertically flipped coordinates of the picture.
+func placeSprite(pic *pixel.PictureData, p layout.ImagePlacement) placedSprite {
+	b := pic.Bounds()
+	frame := pixel.R(
+		float64(p.Src.Min.X),
+		b.Min.Y+b.Max.Y-float64(p.Src.Max.Y),
+		float64(p.Src.Max.X),
+		b.Min.Y+b.Max.Y-float64(p.Src.Min.Y),
+	)
+	mat := pixel.IM.
+		ScaledXY(pixel.ZV, pixel.V(p.Dst.W()/frame.W(), p.Dst.H()/frame.H())).
+		Moved(p.Dst.Center())
+	return placedSprite{pixel.NewSprite(pic, frame), mat}
+}
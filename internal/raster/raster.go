@@ -0,0 +1,57 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// Draw draws the layout onto t, which is a pixelgl window or an
+// ImageTarget. The package does not depend on OpenGL, so that backends that
+// display images rendered in software work without it.
+func Draw(t pixel.Target, l *layout.Layout) {
+	SetupNotificationWindow(l).Draw(t)
+	SetupNotificationText(l.Text).Draw(t)
+}
+
+// ImageBackend renders notifications offscreen into a png file.
+type ImageBackend struct {
+	Path string
+}
+
+func (b ImageBackend) Show(
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	return render.EventNone, renderOffscreen(l).SavePNG(b.Path)
+}
+
+func renderOffscreen(l *layout.Layout) *ImageTarget {
+	target := NewImageTarget(l.Width, l.Height)
+	target.SetColorMask(pixel.Alpha(l.Opacity))
+	Draw(target, l)
+	return target
+}
+
+// RenderImage renders the layout in software for backends that display
+// plain pixel buffers. The image holds premultiplied alpha.
+func RenderImage(l *layout.Layout) *image.RGBA {
+	return renderOffscreen(l).Image()
+}
+
+func fillBox(
+	imd *imdraw.IMDraw,
+	r pixel.Rect,
+	c color.Color,
+) {
+	// Colors with straight alpha (e.g. color.NRGBA) are converted to
+	// pixel's premultiplied representation here.
+	imd.Color = pixel.ToRGBA(c)
+	vs := r.Vertices()
+	imd.Push(vs[0], vs[1], vs[2], vs[3])
+	imd.Polygon(0)
+}
@@ -0,0 +1,254 @@
+package raster
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+var update = flag.Bool("update", false, "update golden images in testdata")
+
+func mustParseColor(t *testing.T, input string) color.Color {
+	t.Helper()
+	c, err := parsing.ParseColor(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return color.NRGBA(c)
+}
+
+func mustParseGradient(t *testing.T, input string) *parsing.Gradient {
+	t.Helper()
+	g, err := parsing.ParseGradient(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func loadPNG(t *testing.T, path string) *image.RGBA {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+func checkGolden(t *testing.T, name string, got *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if err := png.Encode(file, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("could not open golden image (run with -update): %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != got.Bounds() {
+		t.Fatalf("got bounds %v, want %v", got.Bounds(), img.Bounds())
+	}
+
+	// Allow for small rounding differences between architectures.
+	const tolerance = 2
+	var mismatches int
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g := got.RGBAAt(x, y)
+			w := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			for _, d := range []float64{
+				float64(g.R) - float64(w.R),
+				float64(g.G) - float64(w.G),
+				float64(g.B) - float64(w.B),
+				float64(g.A) - float64(w.A),
+			} {
+				if math.Abs(d) > tolerance {
+					mismatches++
+					break
+				}
+			}
+		}
+	}
+	if mismatches > 0 {
+		t.Errorf("%d pixels differ from golden image %s", mismatches, path)
+	}
+}
+
+func TestImageTarget_Golden(t *testing.T) {
+	fs, err := ifont.LoadOpentypeFontSetDefault(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		title, body string
+		width       float64
+		height      float64
+		borderWidth float64
+		borderColor string
+		fgColor     string
+		linkColor   string
+		bg          layout.Background
+		opacity     float64
+		markdown    bool
+	}{
+		{
+			name:        "title_body",
+			title:       "Title",
+			body:        "Body text\nwith two lines",
+			borderWidth: 2,
+			borderColor: "#fff",
+			fgColor:     "#fff",
+			bg:          layout.Background{Color: mustParseColor(t, "#000")},
+			opacity:     1,
+		},
+		{
+			name:        "body_only",
+			body:        "Just a body",
+			borderWidth: 0,
+			borderColor: "#fff",
+			fgColor:     "#28a745",
+			bg:          layout.Background{Color: mustParseColor(t, "#eee")},
+			opacity:     1,
+		},
+		{
+			name:        "fixed_size_thick_border",
+			title:       "Fixed",
+			body:        "size",
+			width:       240,
+			height:      120,
+			borderWidth: 10,
+			borderColor: "SteelBlue",
+			fgColor:     "#000",
+			bg:          layout.Background{Color: mustParseColor(t, "#fff")},
+			opacity:     1,
+		},
+		{
+			name:        "linear_gradient",
+			title:       "Gradient",
+			body:        "linear",
+			borderWidth: 2,
+			borderColor: "#fff",
+			fgColor:     "#fff",
+			bg: layout.Background{Gradient: mustParseGradient(
+				t,
+				"linear-gradient(90deg, #1d2021, SteelBlue 80%)",
+			)},
+			opacity: 1,
+		},
+		{
+			name:        "radial_gradient",
+			body:        "radial",
+			width:       160,
+			height:      80,
+			borderWidth: 0,
+			borderColor: "#000",
+			fgColor:     "#fff",
+			bg: layout.Background{Gradient: mustParseGradient(
+				t,
+				"radial-gradient(red, #000)",
+			)},
+			opacity: 1,
+		},
+		{
+			name:        "translucent",
+			title:       "Translucent",
+			body:        "border and background",
+			borderWidth: 4,
+			borderColor: "rgba(255, 0, 0, 0.5)",
+			fgColor:     "#fff",
+			bg:          layout.Background{Color: mustParseColor(t, "#000000aa")},
+			opacity:     0.8,
+		},
+		{
+			name:        "link",
+			title:       "Deployed",
+			body:        "see https://example.com/42.",
+			borderWidth: 2,
+			borderColor: "#fff",
+			fgColor:     "#fff",
+			linkColor:   "#6cb6ff",
+			bg:          layout.Background{Color: mustParseColor(t, "#000")},
+			opacity:     1,
+		},
+		{
+			name:        "markdown",
+			title:       "Release",
+			body:        "- fix `-d 0`\n  - nested\n```\n$ make\n```",
+			borderWidth: 2,
+			borderColor: "#fff",
+			fgColor:     "#ebdbb2",
+			bg:          layout.Background{Color: mustParseColor(t, "#1d2021")},
+			opacity:     1,
+			markdown:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var linkColor color.Color
+			if tt.linkColor != "" {
+				linkColor = mustParseColor(t, tt.linkColor)
+			}
+			l := layout.New(
+				layout.Config{
+					Fonts:       fs,
+					Width:       tt.width,
+					Height:      tt.height,
+					Padding:     10,
+					BorderWidth: tt.borderWidth,
+					BorderColor: mustParseColor(t, tt.borderColor),
+					Background:  tt.bg,
+					Foreground:  mustParseColor(t, tt.fgColor),
+					LinkColor:   linkColor,
+					Opacity:     tt.opacity,
+					Markdown:    tt.markdown,
+				},
+				&parsing.Notification{Title: tt.title, Body: tt.body},
+			)
+
+			path := filepath.Join(t.TempDir(), "notification.png")
+			ev, err := ImageBackend{Path: path}.Show(l, render.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ev != render.EventNone {
+				t.Errorf("got event %v, want %v", ev, render.EventNone)
+			}
+			checkGolden(t, tt.name, loadPNG(t, path))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/faiface/pixel"
+)
+
+// Event describes how a notification was closed.
+type Event int
+
+const (
+	// EventNone is returned by backends that do not display the
+	// notification interactively.
+	EventNone Event = iota
+	EventTimeout
+	EventClosed
+	EventLeftClick
+	EventRightClick
+	// EventCanceled is returned if the notification was closed through
+	// Options.Cancel.
+	EventCanceled
+	// EventMiddleClick is returned for middle clicks if Options.Copy is
+	// nil.
+	EventMiddleClick
+	// EventDismissed is returned if the notification was closed with the
+	// keyboard, which only the terminal backend supports.
+	EventDismissed
+)
+
+func (e Event) String() string {
+	switch e {
+	case EventTimeout:
+		return "timeout"
+	case EventClosed:
+		return "closed"
+	case EventLeftClick:
+		return "left-click"
+	case EventRightClick:
+		return "right-click"
+	case EventCanceled:
+		return "canceled"
+	case EventMiddleClick:
+		return "middle-click"
+	case EventDismissed:
+		return "dismissed"
+	}
+	return "none"
+}
+
+// ParseEvent parses the name of an event as returned by String, e.g.
+// "left-click".
+func ParseEvent(input string) (Event, error) {
+	name := strings.ToLower(strings.TrimSpace(input))
+	var names []string
+	for e := EventNone + 1; e.String() != "none"; e++ {
+		if e.String() == name {
+			return e, nil
+		}
+		names = append(names, e.String())
+	}
+	return EventNone, fmt.Errorf(
+		"could not parse event %s: %w",
+		input,
+		fmt.Errorf("expected one of %s", strings.Join(names, ", ")),
+	)
+}
+
+type Options struct {
+	Title string
+	// X and Y position the window relative to the top left corner of the
+	// screen. Negative values position it relative to the bottom right
+	// corner.
+	X float64
+	Y float64
+	// Duration after which the notification closes. 0 keeps it open until
+	// it is clicked.
+	Duration time.Duration
+	// ClickThrough passes clicks on to the windows below, so that the
+	// notification only closes after Duration.
+	ClickThrough bool
+	// Sticky shows the notification on all workspaces.
+	Sticky bool
+	// Cancel closes the notification once the channel is closed.
+	Cancel <-chan struct{}
+	// Updates replace the notification that is shown and restart the
+	// timer. Backends lay them out with Relayout from the goroutine that
+	// draws, as the font faces must not be used concurrently.
+	Updates  <-chan *parsing.Notification
+	Relayout func(*parsing.Notification) *layout.Layout
+	// OpenLink opens the URL of a link that was clicked. If it is nil,
+	// clicks on links are handled like other clicks.
+	OpenLink func(url string)
+	// Copy returns the text that a middle click copies to the clipboard.
+	// If it is nil, middle clicks close the notification.
+	Copy func() string
+}
+
+// FeedbackDuration is how long a notification flashes after its text was
+// copied.
+const FeedbackDuration = 300 * time.Millisecond
+
+// A Backend displays a layout and blocks until the notification is closed.
+type Backend interface {
+	Show(l *layout.Layout, opts Options) (Event, error)
+}
+
+// ClickLink opens the link of l at v, given in layout coordinates, and
+// reports whether there was one.
+func ClickLink(l *layout.Layout, opts Options, v pixel.Vec) bool {
+	if opts.OpenLink == nil {
+		return false
+	}
+	url, ok := l.LinkAt(v)
+	if ok {
+		opts.OpenLink(url)
+	}
+	return ok
+}
+
+// Timeout returns a channel that fires after d, or never if d is 0.
+func Timeout(d time.Duration) <-chan time.Time {
+	if d == 0 {
+		return nil
+	}
+	return time.After(d)
+}
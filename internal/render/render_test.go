@@ -0,0 +1,25 @@
+package render
+
+import "testing"
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Event
+		wantErr bool
+	}{
+		{"left-click", EventLeftClick, false},
+		{" Right-Click ", EventRightClick, false},
+		{"timeout", EventTimeout, false},
+		{"canceled", EventCanceled, false},
+		{"none", EventNone, true},
+		{"click", EventNone, true},
+		{"", EventNone, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEvent(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseEvent(%q) = %v, %v, want %v, error %t", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
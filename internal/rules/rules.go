@@ -0,0 +1,221 @@
+package rules
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+)
+
+// Settings are the options a rule can set, by the name used in the
+// configuration file.
+var Settings = []string{
+	"background",
+	"foreground",
+	"link-color",
+	"border-color",
+	"border-width",
+	"font",
+	"font-size",
+	"geometry",
+	"duration",
+	"opacity",
+	"channel",
+	"backend",
+	"sound",
+	"markdown",
+}
+
+// A Rule matches notifications whose fields match all of its matchers and
+// applies its settings to them.
+type Rule struct {
+	Name    string
+	Title   *regexp.Regexp
+	Body    *regexp.Regexp
+	App     *regexp.Regexp
+	Urgency *parsing.Urgency
+
+	Settings map[string]string
+	// Suppress drops matching notifications.
+	Suppress bool
+}
+
+// A Message holds the fields of a notification that rules match on.
+type Message struct {
+	Title   string
+	Body    string
+	App     string
+	Urgency parsing.Urgency
+}
+
+func (r *Rule) Matches(m Message) bool {
+	matches := func(re *regexp.Regexp, s string) bool {
+		return re == nil || re.MatchString(s)
+	}
+	return matches(r.Title, m.Title) &&
+		matches(r.Body, m.Body) &&
+		matches(r.App, m.App) &&
+		(r.Urgency == nil || *r.Urgency == m.Urgency)
+}
+
+// Match applies the rules that match m in order, so that later rules
+// override the settings of earlier ones.
+func Match(rules []Rule, m Message) (settings map[string]string, suppress bool) {
+	settings = make(map[string]string)
+	for _, r := range rules {
+		if !r.Matches(m) {
+			continue
+		}
+		for k, v := range r.Settings {
+			settings[k] = v
+		}
+		suppress = suppress || r.Suppress
+	}
+	return settings, suppress
+}
+
+// Path returns the default path of the configuration file.
+func Path(app string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, app, "config")
+}
+
+// Load reads the rules from the configuration file at path. A missing file
+// has no rules.
+func Load(path string) ([]Rule, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open config file: %w", err)
+	}
+	defer file.Close()
+	rules, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Parse parses rules in an INI-like format:
+//
+//	# comment
+//	[rule ci-failed]
+//	title = ^CI$
+//	body = (?i)failed
+//	background = #DC3545
+//
+// Every section starts a rule. title, body and app are regular expressions
+// and urgency is low, normal or critical.
+func Parse(r io.Reader) ([]Rule, error) {
+	var (
+		rules   []Rule
+		current *Rule
+		lineNo  int
+	)
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("line %d: %s", lineNo, fmt.Sprintf(format, args...))
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fail("expected ] at the end of section %s", line)
+			}
+			fields := strings.Fields(line[1 : len(line)-1])
+			if len(fields) == 0 || fields[0] != "rule" {
+				return nil, fail("unknown section %s, expected [rule <name>]", line)
+			}
+			rules = append(rules, Rule{
+				Name:     strings.Join(fields[1:], " "),
+				Settings: make(map[string]string),
+			})
+			current = &rules[len(rules)-1]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fail("expected <key> = <value>, got %s", line)
+		}
+		if current == nil {
+			return nil, fail("%s is outside of a [rule] section", line)
+		}
+		key = strings.TrimSpace(key)
+		value = unquote(strings.TrimSpace(value))
+		if err := current.set(key, value); err != nil {
+			return nil, fail("%v", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// unquote removes double quotes around value, which keep its surrounding
+// spaces.
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+	}
+	return value
+}
+
+func (r *Rule) set(key, value string) error {
+	compile := func() (*regexp.Regexp, error) {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", key, err)
+		}
+		return re, nil
+	}
+
+	var err error
+	switch key {
+	case "title":
+		r.Title, err = compile()
+	case "body":
+		r.Body, err = compile()
+	case "app":
+		r.App, err = compile()
+	case "urgency":
+		var u parsing.Urgency
+		u, err = parsing.ParseUrgency(value)
+		r.Urgency = &u
+	case "suppress":
+		r.Suppress, err = strconv.ParseBool(value)
+		if err != nil {
+			err = fmt.Errorf("could not parse suppress %s: expected true or false", value)
+		}
+	default:
+		for _, s := range Settings {
+			if key == s {
+				r.Settings[key] = value
+				return nil
+			}
+		}
+		err = fmt.Errorf("unknown key %s", key)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+)
+
+const config = `
+# Failed builds stay until they are clicked.
+[rule ci failed]
+title = ^CI$
+body = (?i)failed
+background = #DC3545
+duration = 0
+
+[rule chat]
+app = ^(slack|discord)$
+channel = chat
+font = " Inconsolata "
+
+; Low urgency messages of the updater are not shown.
+[rule updates]
+app = updater
+urgency = low
+suppress = true
+`
+
+func TestMatch(t *testing.T) {
+	rules, err := Parse(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		m            Message
+		wantSettings map[string]string
+		wantSuppress bool
+	}{
+		{
+			"ci failed",
+			Message{Title: "CI", Body: "Build FAILED"},
+			map[string]string{"background": "#DC3545", "duration": "0"},
+			false,
+		},
+		{"ci passed", Message{Title: "CI", Body: "Build passed"}, map[string]string{}, false},
+		{
+			"chat",
+			Message{Body: "hi", App: "slack"},
+			map[string]string{"channel": "chat", "font": " Inconsolata "},
+			false,
+		},
+		{"updater low", Message{App: "updater", Urgency: parsing.UrgencyLow}, map[string]string{}, true},
+		{"updater normal", Message{App: "updater", Urgency: parsing.UrgencyNormal}, map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, suppress := Match(rules, tt.m)
+			if !reflect.DeepEqual(settings, tt.wantSettings) {
+				t.Errorf("got settings %v, want %v", settings, tt.wantSettings)
+			}
+			if suppress != tt.wantSuppress {
+				t.Errorf("got suppress %v, want %v", suppress, tt.wantSuppress)
+			}
+		})
+	}
+}
+
+func TestMatch_Order(t *testing.T) {
+	rules, err := Parse(strings.NewReader(`
+[rule all]
+background = #000
+foreground = #fff
+[rule errors]
+title = Error
+background = #f00
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := Match(rules, Message{Title: "Error"})
+	want := map[string]string{"background": "#f00", "foreground": "#fff"}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("got %v, want %v", settings, want)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"title = x", "line 1: title = x is outside of a [rule] section"},
+		{"[rule a]\ntitle = (", "line 2: could not parse title"},
+		{"[rule a]\nurgency = urgent", "line 2:"},
+		{"[rule a]\nsuppress = maybe", "line 2: could not parse suppress"},
+		{"[rule a]\ncolor = red", "line 2: unknown key color"},
+		{"[rule a]\nbackground", "line 2: expected <key> = <value>"},
+		{"[global]", "line 1: unknown section [global]"},
+		{"[rule a", "line 1: expected ]"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): got error %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	rules, err := Load(filepath.Join(dir, "missing"))
+	if err != nil || rules != nil {
+		t.Errorf("got %v, %v for missing file, want no rules", rules, err)
+	}
+
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range rules {
+		names = append(names, r.Name)
+	}
+	if want := []string{"ci failed", "chat", "updates"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got rules %v, want %v", names, want)
+	}
+}
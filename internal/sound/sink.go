@@ -0,0 +1,156 @@
+package sound
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// A Sink plays sounds.
+type Sink interface {
+	// Play blocks until the sound has been played or stop is closed.
+	Play(s *Sound, stop <-chan struct{}) error
+}
+
+// Null discards sounds, e.g. if there is no audio output.
+type Null struct{}
+
+func (Null) Play(*Sound, <-chan struct{}) error {
+	return nil
+}
+
+// Command plays sounds by writing them to the stdin of a command.
+type Command struct {
+	Name string
+	// Args returns the arguments of the command for the format of s.
+	Args func(s *Sound) []string
+	// WAV writes a WAV file instead of raw 16-bit little-endian samples.
+	WAV bool
+}
+
+// PulseAudio plays sounds with pacat, which PipeWire also provides with
+// pipewire-pulse.
+var PulseAudio = Command{
+	Name: "pacat",
+	Args: func(s *Sound) []string {
+		return []string{
+			"--playback",
+			"--raw",
+			"--format=s16le",
+			fmt.Sprintf("--rate=%d", s.SampleRate),
+			fmt.Sprintf("--channels=%d", s.Channels),
+		}
+	},
+}
+
+// PipeWire plays sounds with pw-cat.
+var PipeWire = Command{
+	Name: "pw-cat",
+	Args: func(s *Sound) []string {
+		return []string{
+			"--playback",
+			"--format=s16",
+			fmt.Sprintf("--rate=%d", s.SampleRate),
+			fmt.Sprintf("--channels=%d", s.Channels),
+			"-",
+		}
+	},
+}
+
+// Player returns a sink that writes WAV files to the stdin of the command
+// line, e.g. "aplay -q -".
+func Player(command string) Command {
+	fields := strings.Fields(command)
+	return Command{
+		Name: fields[0],
+		Args: func(*Sound) []string { return fields[1:] },
+		WAV:  true,
+	}
+}
+
+// players are used if no sound server is available.
+var players = []string{
+	"aplay -q -",
+	"ffplay -nodisp -autoexit -loglevel quiet -",
+}
+
+// Fallback plays sounds with the first of its sinks that does not fail,
+// e.g. because pacat is installed but no sound server is running.
+type Fallback []Sink
+
+func (f Fallback) Play(s *Sound, stop <-chan struct{}) error {
+	var errs []error
+	for _, sink := range f {
+		err := sink.Play(s, stop)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+		select {
+		case <-stop:
+			return errors.Join(errs...)
+		default:
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DefaultSink returns the sound servers and players that are installed, in
+// order of preference, or Null if there is none.
+func DefaultSink() Sink {
+	sinks := []Command{PulseAudio, PipeWire}
+	for _, p := range players {
+		sinks = append(sinks, Player(p))
+	}
+	var installed Fallback
+	for _, sink := range sinks {
+		if _, err := exec.LookPath(sink.Name); err == nil {
+			installed = append(installed, sink)
+		}
+	}
+	if len(installed) == 0 {
+		return Null{}
+	}
+	return installed
+}
+
+func (c Command) Play(s *Sound, stop <-chan struct{}) error {
+	cmd := exec.Command(c.Name, c.Args(s)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start %s: %w", c.Name, err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	w := bufio.NewWriter(stdin)
+	if c.WAV {
+		err = EncodeWAV(w, s)
+	} else {
+		err = binary.Write(w, binary.LittleEndian, s.Samples)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	stdin.Close()
+	if waitErr := cmd.Wait(); waitErr != nil {
+		return fmt.Errorf("could not play sound with %s: %w", c.Name, waitErr)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write sound to %s: %w", c.Name, err)
+	}
+	return nil
+}
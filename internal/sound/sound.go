@@ -0,0 +1,66 @@
+package sound
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// A Sound holds interleaved 16-bit PCM samples.
+type Sound struct {
+	SampleRate int
+	Channels   int
+	Samples    []int16
+}
+
+// Load decodes the WAV or Ogg Vorbis file at path.
+func Load(path string) (*Sound, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open sound: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	magic, _ := r.Peek(4)
+	var s *Sound
+	switch {
+	case bytes.Equal(magic, []byte("RIFF")):
+		s, err = DecodeWAV(r)
+	case bytes.Equal(magic, []byte("OggS")):
+		s, err = decodeOgg(r)
+	default:
+		return nil, fmt.Errorf("could not decode sound %s: expected a WAV or Ogg Vorbis file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode sound %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func decodeOgg(r *bufio.Reader) (*Sound, error) {
+	samples, format, err := oggvorbis.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := Sound{
+		SampleRate: format.SampleRate,
+		Channels:   format.Channels,
+		Samples:    make([]int16, len(samples)),
+	}
+	for i, v := range samples {
+		s.Samples[i] = fromFloat(float64(v))
+	}
+	return &s, nil
+}
+
+// fromFloat converts a sample between -1 and 1, clipping it if it is out
+// of range.
+func fromFloat(v float64) int16 {
+	v = math.Max(-1, math.Min(1, v))
+	return int16(math.Round(v * math.MaxInt16))
+}
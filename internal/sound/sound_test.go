@@ -0,0 +1,110 @@
+package sound
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load(filepath.Join("testdata", "cue.ogg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SampleRate != 44100 || s.Channels != 1 || len(s.Samples) == 0 {
+		t.Errorf("got %d Hz with %d channels and %d samples", s.SampleRate, s.Channels, len(s.Samples))
+	}
+
+	path := filepath.Join(t.TempDir(), "cue.wav")
+	if err := os.WriteFile(path, wav(formatPCM, 1, 8, []byte{128}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Load(path); err != nil || len(s.Samples) != 1 {
+		t.Errorf("got %+v, %v for WAV file, want 1 sample", s, err)
+	}
+
+	if err := os.WriteFile(path, []byte("ID3 mp3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("want error for unknown format")
+	}
+}
+
+func TestCommand_Play(t *testing.T) {
+	s := &Sound{SampleRate: 8000, Channels: 1, Samples: []int16{1, 2, -3}}
+	out := filepath.Join(t.TempDir(), "out")
+	sink := Command{
+		Name: "sh",
+		Args: func(*Sound) []string { return []string{"-c", "cat > " + out} },
+	}
+	if err := sink.Play(s, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	binary.Write(&want, binary.LittleEndian, s.Samples)
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("got %v, want raw samples %v", got, want.Bytes())
+	}
+
+	sink.WAV = true
+	if err := sink.Play(s, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = os.ReadFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(got, []byte("RIFF")) {
+		t.Errorf("got %q, want a WAV file", got)
+	}
+
+	if err := (Command{Name: "false", Args: sink.Args}).Play(s, nil); err == nil {
+		t.Error("want error for failing command")
+	}
+	if err := (Null{}).Play(s, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFallback_Play(t *testing.T) {
+	s := &Sound{SampleRate: 8000, Channels: 1, Samples: []int16{1}}
+	out := filepath.Join(t.TempDir(), "out")
+	failing := Command{Name: "false", Args: func(*Sound) []string { return nil }}
+	writing := Command{
+		Name: "sh",
+		Args: func(*Sound) []string { return []string{"-c", "cat > " + out} },
+	}
+	if err := (Fallback{failing, writing}).Play(s, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("sound was not played by the next sink: %v", err)
+	}
+	if err := (Fallback{failing, failing}).Play(s, nil); err == nil {
+		t.Error("want error if every sink fails")
+	}
+}
+
+func TestCommand_Play_Stop(t *testing.T) {
+	s := &Sound{SampleRate: 8000, Channels: 1, Samples: []int16{1}}
+	sink := Command{
+		Name: "sh",
+		Args: func(*Sound) []string { return []string{"-c", "cat > /dev/null; sleep 10"} },
+	}
+	stop := make(chan struct{})
+	close(stop)
+	start := time.Now()
+	if err := sink.Play(s, stop); err == nil {
+		t.Error("want error for stopped command")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("stopped command took %v", d)
+	}
+}
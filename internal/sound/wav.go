@@ -0,0 +1,139 @@
+package sound
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xfffe
+)
+
+// DecodeWAV decodes a WAV file with integer PCM samples of 8, 16, 24 or 32
+// bits or float samples of 32 or 64 bits.
+func DecodeWAV(r io.Reader) (*Sound, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("could not read RIFF header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+
+	var (
+		format        uint16
+		channels      int
+		sampleRate    int
+		bitsPerSample int
+		hasFormat     bool
+	)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("could not find data chunk: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("fmt chunk of %d bytes is too short", size)
+			}
+			// Chunks are padded to an even size.
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, fmt.Errorf("could not read fmt chunk: %w", err)
+			}
+			format = binary.LittleEndian.Uint16(b[0:2])
+			channels = int(binary.LittleEndian.Uint16(b[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(b[4:8]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(b[14:16]))
+			if format == formatExtensible && size >= 26 {
+				// The format is the start of the sub format GUID.
+				format = binary.LittleEndian.Uint16(b[24:26])
+			}
+			if channels == 0 || sampleRate == 0 {
+				return nil, errors.New("fmt chunk has no channels or sample rate")
+			}
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			decode, err := sampleDecoder(format, bitsPerSample)
+			if err != nil {
+				return nil, err
+			}
+			// Some writers leave the size at its maximum when streaming.
+			data, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, fmt.Errorf("could not read data chunk: %w", err)
+			}
+			width := bitsPerSample / 8
+			s := Sound{
+				SampleRate: sampleRate,
+				Channels:   channels,
+				Samples:    make([]int16, len(data)/width),
+			}
+			for i := range s.Samples {
+				s.Samples[i] = decode(data[i*width:])
+			}
+			return &s, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, fmt.Errorf("could not skip %s chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+func sampleDecoder(format uint16, bits int) (func([]byte) int16, error) {
+	switch {
+	case format == formatPCM && bits == 8:
+		// 8-bit samples are unsigned.
+		return func(b []byte) int16 { return (int16(b[0]) - 128) << 8 }, nil
+	case format == formatPCM && bits == 16:
+		return func(b []byte) int16 { return int16(binary.LittleEndian.Uint16(b)) }, nil
+	case format == formatPCM && bits == 24:
+		return func(b []byte) int16 { return int16(b[1]) | int16(int8(b[2]))<<8 }, nil
+	case format == formatPCM && bits == 32:
+		return func(b []byte) int16 { return int16(binary.LittleEndian.Uint32(b) >> 16) }, nil
+	case format == formatFloat && bits == 32:
+		return func(b []byte) int16 {
+			return fromFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+		}, nil
+	case format == formatFloat && bits == 64:
+		return func(b []byte) int16 {
+			return fromFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported WAV format %d with %d bits per sample", format, bits)
+}
+
+// EncodeWAV writes the sound as a 16-bit PCM WAV file.
+func EncodeWAV(w io.Writer, s *Sound) error {
+	dataSize := 2 * len(s.Samples)
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+dataSize))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], formatPCM)
+	binary.LittleEndian.PutUint16(header[22:], uint16(s.Channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(s.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(2*s.SampleRate*s.Channels))
+	binary.LittleEndian.PutUint16(header[32:], uint16(2*s.Channels))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(dataSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, s.Samples)
+}
@@ -0,0 +1,109 @@
+package sound
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wav builds a WAV file with the given format and data chunk, preceded by
+// a LIST chunk of odd size that has to be skipped.
+func wav(format, channels, bits int, data []byte) []byte {
+	var b bytes.Buffer
+	le := func(v any) { binary.Write(&b, binary.LittleEndian, v) }
+	b.WriteString("RIFF")
+	le(uint32(0))
+	b.WriteString("WAVEfmt ")
+	le(uint32(16))
+	le(uint16(format))
+	le(uint16(channels))
+	le(uint32(8000))
+	le(uint32(8000 * channels * bits / 8))
+	le(uint16(channels * bits / 8))
+	le(uint16(bits))
+	b.WriteString("LIST")
+	le(uint32(3))
+	b.WriteString("abc\x00")
+	b.WriteString("data")
+	le(uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func le(v any) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, v)
+	return b.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	want := []int16{math.MinInt16, 0, 0x7f00}
+	// Float samples are symmetric around 0.
+	wantFloat := []int16{-math.MaxInt16, 0, 0x7f00}
+	tests := []struct {
+		name   string
+		format int
+		bits   int
+		data   []byte
+		want   []int16
+	}{
+		{"8-bit", formatPCM, 8, []byte{0, 128, 255}, want},
+		{"16-bit", formatPCM, 16, le([]int16{math.MinInt16, 0, 0x7f00}), want},
+		{"24-bit", formatPCM, 24, []byte{0, 0, 0x80, 0xff, 0, 0, 0, 0, 0x7f}, want},
+		{"32-bit", formatPCM, 32, le([]int32{math.MinInt32, 0, 0x7f00ffff}), want},
+		{"float", formatFloat, 32, le([]float32{-2, 0, float32(0x7f00) / math.MaxInt16}), wantFloat},
+		{"double", formatFloat, 64, le([]float64{-1, 0, float64(0x7f00) / math.MaxInt16}), wantFloat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := DecodeWAV(bytes.NewReader(wav(tt.format, 1, tt.bits, tt.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.SampleRate != 8000 || s.Channels != 1 {
+				t.Errorf("got %d Hz with %d channels, want 8000 Hz with 1 channel", s.SampleRate, s.Channels)
+			}
+			if !reflect.DeepEqual(s.Samples, tt.want) {
+				t.Errorf("got samples %v, want %v", s.Samples, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeWAV_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"not riff", []byte("OggS00000000"), "not a WAV file"},
+		{"unsupported", wav(formatPCM, 1, 12, nil), "unsupported WAV format"},
+		{"no data", wav(formatPCM, 1, 16, nil)[:36], "could not find data chunk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeWAV(bytes.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWAV(t *testing.T) {
+	want := &Sound{SampleRate: 44100, Channels: 2, Samples: []int16{1, -1, 300, -300}}
+	var b bytes.Buffer
+	if err := EncodeWAV(&b, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeWAV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package term
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/faiface/pixel"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/image/font"
+)
+
+const (
+	padX     = 2
+	padY     = 1
+	tabWidth = 4
+)
+
+// A cell is a rune, including combining marks, that occupies width
+// columns of the terminal.
+type cell struct {
+	text  string
+	width int
+}
+
+func toCells(s string) []cell {
+	var (
+		cells []cell
+		col   int
+	)
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			for n := tabWidth - col%tabWidth; n > 0; n-- {
+				cells = append(cells, cell{" ", 1})
+				col++
+			}
+			continue
+		case r == '\r':
+			continue
+		case unicode.IsControl(r):
+			r = unicode.ReplacementChar
+		}
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			if len(cells) > 0 {
+				cells[len(cells)-1].text += string(r)
+			}
+			continue
+		}
+		cells = append(cells, cell{string(r), w})
+		col += w
+	}
+	return cells
+}
+
+func width(cells []cell) int {
+	var w int
+	for _, c := range cells {
+		w += c.width
+	}
+	return w
+}
+
+// A textLine is the text of the runs on a line of the layout. Runs that
+// start further right than the text, like the items of Markdown lists, are
+// indented with spaces of the face of the first run of the line.
+type textLine struct {
+	text  string
+	bold  bool
+	color color.Color
+}
+
+func textLines(l *layout.Layout) []textLine {
+	left := math.Inf(1)
+	for _, run := range l.Text {
+		left = math.Min(left, run.Dot.X)
+	}
+	var (
+		lines []textLine
+		space float64
+	)
+	for i, run := range l.Text {
+		if i == 0 || run.Line != l.Text[i-1].Line {
+			lines = append(lines, textLine{bold: run.Bold, color: run.Color})
+			space = float64(font.MeasureString(run.Face, " ")) / (1 << 6)
+		}
+		tl := &lines[len(lines)-1]
+		// Runs are rounded down to columns, as the padding of inline code
+		// is narrower than a space.
+		if space > 0 {
+			col := int(math.Floor((run.Dot.X-left)/space + 1e-6))
+			if n := col - width(toCells(tl.text)); n > 0 {
+				tl.text += strings.Repeat(" ", n)
+			}
+		}
+		tl.text += run.Text
+	}
+	return lines
+}
+
+type style struct {
+	bold bool
+	fg   color.Color
+	bg   color.Color
+}
+
+// sgr returns the escape sequence that resets all attributes and selects
+// the style. Nil colors select the default colors of the terminal.
+func (s style) sgr() string {
+	var b strings.Builder
+	b.WriteString("\x1b[0")
+	if s.bold {
+		b.WriteString(";1")
+	}
+	if s.fg != nil {
+		c := color.NRGBAModel.Convert(s.fg).(color.NRGBA)
+		fmt.Fprintf(&b, ";38;2;%d;%d;%d", c.R, c.G, c.B)
+	}
+	if s.bg != nil {
+		c := color.NRGBAModel.Convert(s.bg).(color.NRGBA)
+		fmt.Fprintf(&b, ";48;2;%d;%d;%d", c.R, c.G, c.B)
+	}
+	b.WriteString("m")
+	return b.String()
+}
+
+const reset = "\x1b[0m"
+
+// renderPanel draws the layout as a box of terminal cells with truecolor
+// escape sequences and returns its lines. Translucency and background
+// images cannot be shown in a terminal and are dropped.
+func renderPanel(l *layout.Layout) []string {
+	l = l.Opaque()
+
+	text := textLines(l)
+	lines := make([][]cell, len(text))
+	textWidth := 0
+	for i, tl := range text {
+		lines[i] = toCells(tl.text)
+		if w := width(lines[i]); w > textWidth {
+			textWidth = w
+		}
+	}
+	innerW := textWidth + 2*padX
+	innerH := len(lines) + 2*padY
+
+	var borderColor color.Color
+	if len(l.Border) > 0 {
+		borderColor = l.Border[0].Color
+	}
+	border := style{fg: borderColor}.sgr()
+
+	// bgAt samples the background at the center of the cell in column col
+	// and row row, mapping the panel onto the content box of the layout.
+	r := l.Content.Rect
+	bgAt := func(col, row int) color.Color {
+		return l.Content.ColorAt(pixel.V(
+			r.Min.X+(float64(col)+0.5)/float64(innerW)*r.W(),
+			r.Max.Y-(float64(row)+0.5)/float64(innerH)*r.H(),
+		))
+	}
+
+	var out []string
+	if borderColor != nil {
+		out = append(out, border+"╭"+strings.Repeat("─", innerW)+"╮"+reset)
+	}
+	for row := 0; row < innerH; row++ {
+		var (
+			b     strings.Builder
+			cells []cell
+			fg    color.Color
+			bold  bool
+			cur   string
+		)
+		if i := row - padY; i >= 0 && i < len(lines) {
+			cells = lines[i]
+			fg = text[i].color
+			bold = text[i].bold
+		}
+		if borderColor != nil {
+			b.WriteString(border + "│")
+			cur = border
+		}
+		for col := 0; col < innerW; {
+			c := cell{" ", 1}
+			if i := col - padX; i >= 0 && len(cells) > 0 {
+				c, cells = cells[0], cells[1:]
+			}
+			if s := (style{bold, fg, bgAt(col, row)}).sgr(); s != cur {
+				b.WriteString(s)
+				cur = s
+			}
+			b.WriteString(c.text)
+			col += c.width
+		}
+		if borderColor != nil {
+			b.WriteString(border + "│")
+		}
+		b.WriteString(reset)
+		out = append(out, b.String())
+	}
+	if borderColor != nil {
+		out = append(out, border+"╰"+strings.Repeat("─", innerW)+"╯"+reset)
+	}
+	return out
+}
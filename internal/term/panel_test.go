@@ -0,0 +1,123 @@
+package term
+
+import (
+	"image/color"
+	"regexp"
+	"strings"
+	"testing"
+
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/mattn/go-runewidth"
+)
+
+var escape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func testConfig(t *testing.T, borderWidth float64) layout.Config {
+	t.Helper()
+	fs, err := ifont.LoadOpentypeFontSetDefault(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layout.Config{
+		Fonts:       fs,
+		Padding:     10,
+		BorderWidth: borderWidth,
+		BorderColor: color.NRGBA{R: 0xff, A: 0xff},
+		Background:  layout.Background{Color: color.NRGBA{B: 0xff, A: 0x80}},
+		Foreground:  color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Opacity:     1,
+	}
+}
+
+func testLayout(t *testing.T, input string, borderWidth float64) *layout.Layout {
+	t.Helper()
+	return layout.New(testConfig(t, borderWidth), parsing.ParseNotification(input))
+}
+
+func TestToCells(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"ab", []string{"a", "b"}},
+		{"a\tb", []string{"a", " ", " ", " ", "b"}},
+		{"\x1b[2J", []string{"�", "[", "2", "J"}},
+		{"é", []string{"é"}},
+		{"漢", []string{"漢"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range toCells(tt.input) {
+			got = append(got, c.text)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("toCells(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRenderPanel(t *testing.T) {
+	lines := renderPanel(testLayout(t, "[Title]Body\n漢字", 2))
+	want := []string{
+		"╭─────────╮",
+		"│         │",
+		"│  Title  │",
+		"│  Body   │",
+		"│  漢字   │",
+		"│         │",
+		"╰─────────╯",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		plain := escape.ReplaceAllString(line, "")
+		if plain != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, plain, want[i])
+		}
+		if w := runewidth.StringWidth(plain); w != 11 {
+			t.Errorf("line %d: got width %d, want 11", i, w)
+		}
+	}
+	if !strings.Contains(lines[2], "\x1b[0;1;38;2;255;255;255;48;2;0;0;255m  Title") {
+		t.Errorf("want bold title on opaque background, got %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[0], "\x1b[0;38;2;255;0;0m") {
+		t.Errorf("want red border, got %q", lines[0])
+	}
+}
+
+func TestRenderPanel_NoBorder(t *testing.T) {
+	lines := renderPanel(testLayout(t, "Body", 0))
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	if plain := escape.ReplaceAllString(lines[1], ""); plain != "  Body  " {
+		t.Errorf("got %q, want %q", plain, "  Body  ")
+	}
+}
+
+func TestRenderPanel_Markdown(t *testing.T) {
+	cfg := testConfig(t, 0)
+	cfg.Markdown = true
+	l := layout.New(cfg, parsing.ParseNotification("[Title]- run `make`\n  - nested\n```\ncode\n```"))
+	want := []string{
+		"              ",
+		"  Title       ",
+		"  • run make  ",
+		"    • nested  ",
+		"  code        ",
+		"              ",
+	}
+	lines := renderPanel(l)
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if plain := escape.ReplaceAllString(line, ""); plain != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, plain, want[i])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package term
+
+import (
+	"errors"
+	"os"
+
+	"github.com/LinusMB/Notify/internal/render"
+	"golang.org/x/sys/unix"
+)
+
+// HasDisplay reports whether an X11 or Wayland display is available.
+func HasDisplay() bool {
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// Fallback returns the backend to use when there is no display: the
+// controlling terminal if notify runs in its foreground and tmux otherwise.
+// A background job (e.g. notify ... &) must not use the terminal, as
+// changing its mode or reading from it stops the job.
+func Fallback() (render.Backend, error) {
+	if tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0); err == nil {
+		fg := foreground(tty)
+		tty.Close()
+		if fg {
+			return TTYBackend{}, nil
+		}
+	}
+	if os.Getenv("TMUX") != "" {
+		return TmuxBackend{}, nil
+	}
+	return nil, errors.New(
+		"neither a display nor a terminal is available (notify cannot use the terminal in the background)",
+	)
+}
+
+// foreground reports whether the process group of notify is the foreground
+// process group of the terminal.
+func foreground(tty *os.File) bool {
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == unix.Getpgrp()
+}
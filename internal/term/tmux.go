@@ -0,0 +1,117 @@
+package term
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+// TmuxBackend shows notifications in the status line of the tmux client,
+// which works without a terminal attached to the process. The message
+// cannot be clicked and always closes after the duration, which must not be
+// 0: tmux would keep the message until a key is pressed without telling
+// when that happens.
+type TmuxBackend struct{}
+
+func (TmuxBackend) Show(
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	if opts.Duration == 0 {
+		return render.EventNone, errors.New(
+			"the tmux status line cannot show a notification without a duration, -d must not be 0",
+		)
+	}
+	if err := displayMessage(l, opts.Duration); err != nil {
+		return render.EventNone, err
+	}
+	timeout := time.After(opts.Duration)
+	for {
+		select {
+		case <-timeout:
+			return render.EventTimeout, nil
+		case <-opts.Cancel:
+			return render.EventCanceled, nil
+		case n := <-opts.Updates:
+			if err := displayMessage(opts.Relayout(n), opts.Duration); err != nil {
+				return render.EventNone, err
+			}
+			timeout = time.After(opts.Duration)
+		}
+	}
+}
+
+func displayMessage(l *layout.Layout, d time.Duration) error {
+	cmd := exec.Command(
+		"tmux",
+		"display-message",
+		"-d",
+		strconv.FormatInt(d.Milliseconds(), 10),
+		tmuxMessage(l),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf(
+			"could not run tmux display-message: %w: %s",
+			err,
+			strings.TrimSpace(string(out)),
+		)
+	}
+	return nil
+}
+
+func tmuxColor(c color.Color) string {
+	if c == nil {
+		return "default"
+	}
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", nc.R, nc.G, nc.B)
+}
+
+// tmuxEscape makes s safe to be used in a tmux message, which is expanded
+// as a format.
+func tmuxEscape(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.ReplaceAll(s, "#", "##")
+}
+
+// tmuxMessage joins the lines of the notification into a single styled
+// line. Gradients are approximated by the color at their center.
+func tmuxMessage(l *layout.Layout) string {
+	l = l.Opaque()
+	bg := l.Content.ColorAt(l.Content.Rect.Center())
+
+	var (
+		b    strings.Builder
+		bold bool
+	)
+	fmt.Fprintf(&b, "#[bg=%s] ", tmuxColor(bg))
+	for i, tl := range textLines(l) {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		if tl.bold != bold || i == 0 {
+			attr := "nobold"
+			if tl.bold {
+				attr = "bold"
+			}
+			fmt.Fprintf(&b, "#[fg=%s,%s]", tmuxColor(tl.color), attr)
+			bold = tl.bold
+		}
+		b.WriteString(tmuxEscape(tl.text))
+	}
+	b.WriteString(" ")
+	return b.String()
+}
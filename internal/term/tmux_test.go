@@ -0,0 +1,22 @@
+package term
+
+import (
+	"testing"
+
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+func TestTmuxMessage(t *testing.T) {
+	got := tmuxMessage(testLayout(t, "[50# done]#{pane_id}\nnext", 2))
+	want := "#[bg=#0000ff] #[fg=#ffffff,bold]50## done #[fg=#ffffff,nobold]##{pane_id} next "
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTmuxBackend_NoDuration(t *testing.T) {
+	ev, err := TmuxBackend{}.Show(testLayout(t, "Body", 2), render.Options{})
+	if err == nil {
+		t.Fatalf("got event %v, want an error for -d 0", ev)
+	}
+}
@@ -0,0 +1,136 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/render"
+	"golang.org/x/sys/unix"
+)
+
+const ttyPath = "/dev/tty"
+
+// TTYBackend draws notifications as a panel on the controlling terminal.
+// Enter, space and y act like a left click, Escape and n like a right click
+// and q or Ctrl-C close the notification.
+type TTYBackend struct{}
+
+func (TTYBackend) Show(
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		return render.EventNone, fmt.Errorf("could not open terminal: %w", err)
+	}
+	defer tty.Close()
+
+	fd := int(tty.Fd())
+	state, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return render.EventNone, fmt.Errorf("could not get terminal state: %w", err)
+	}
+	raw := *state
+	makeRaw(&raw)
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return render.EventNone, fmt.Errorf("could not set terminal state: %w", err)
+	}
+	defer unix.IoctlSetTermios(fd, unix.TCSETS, state)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, unix.SIGINT, unix.SIGTERM, unix.SIGHUP)
+	defer signal.Stop(sigs)
+
+	// Raw mode disables output processing, hence lines are separated by
+	// \r\n. The panel is erased again by moving the cursor back to its
+	// first line, which also works if drawing it scrolled the terminal.
+	var lines []string
+	draw := func(l *layout.Layout) {
+		lines = renderPanel(l)
+		fmt.Fprint(tty, "\r"+strings.Join(lines, "\r\n"))
+	}
+	erase := func() {
+		if len(lines) > 1 {
+			fmt.Fprintf(tty, "\x1b[%dA", len(lines)-1)
+		}
+		fmt.Fprint(tty, "\r\x1b[J")
+	}
+	fmt.Fprint(tty, "\x1b[?25l")
+	draw(l)
+	defer func() {
+		erase()
+		fmt.Fprint(tty, "\x1b[?25h")
+	}()
+
+	// Without input the notification is only closed after the duration.
+	// The key is buffered, so that readKeys does not block once Show has
+	// returned because of a timeout.
+	keys := make(chan render.Event, 1)
+	if !opts.ClickThrough {
+		go readKeys(tty, keys)
+	}
+
+	timeout := render.Timeout(opts.Duration)
+	for {
+		select {
+		case ev := <-keys:
+			return ev, nil
+		case <-sigs:
+			return render.EventClosed, nil
+		case <-timeout:
+			return render.EventTimeout, nil
+		case <-opts.Cancel:
+			return render.EventCanceled, nil
+		case n := <-opts.Updates:
+			erase()
+			draw(opts.Relayout(n))
+			timeout = render.Timeout(opts.Duration)
+		}
+	}
+}
+
+// makeRaw is the equivalent of cfmakeraw(3).
+func makeRaw(t *unix.Termios) {
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP |
+		unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB
+	t.Cflag |= unix.CS8
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+}
+
+func readKeys(tty *os.File, keys chan<- render.Event) {
+	buf := make([]byte, 64)
+	for {
+		n, err := tty.Read(buf)
+		if err != nil {
+			return
+		}
+		if ev, ok := keyEvent(buf[:n]); ok {
+			keys <- ev
+			return
+		}
+	}
+}
+
+// keyEvent maps a single key press to an event. Escape sequences, e.g. of
+// arrow keys, are read at once and ignored.
+func keyEvent(input []byte) (render.Event, bool) {
+	if len(input) != 1 {
+		return render.EventNone, false
+	}
+	switch input[0] {
+	case '\r', '\n', ' ', 'y':
+		return render.EventLeftClick, true
+	case 0x1b, 'n':
+		return render.EventRightClick, true
+	case 'q', 0x03, 0x04:
+		return render.EventDismissed, true
+	}
+	return render.EventNone, false
+}
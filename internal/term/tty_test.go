@@ -0,0 +1,33 @@
+package term
+
+import (
+	"testing"
+
+	"github.com/LinusMB/Notify/internal/render"
+)
+
+func TestKeyEvent(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   render.Event
+		wantOk bool
+	}{
+		{"\r", render.EventLeftClick, true},
+		{" ", render.EventLeftClick, true},
+		{"\x1b", render.EventRightClick, true},
+		{"n", render.EventRightClick, true},
+		{"q", render.EventDismissed, true},
+		{"\x03", render.EventDismissed, true},
+		{"\x1b[A", render.EventNone, false},
+		{"a", render.EventNone, false},
+	}
+	for _, tt := range tests {
+		got, ok := keyEvent([]byte(tt.input))
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf(
+				"keyEvent(%q) = %v, %v, want %v, %v",
+				tt.input, got, ok, tt.want, tt.wantOk,
+			)
+		}
+	}
+}
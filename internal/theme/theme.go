@@ -0,0 +1,97 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+)
+
+const XrdbSource = "xrdb"
+
+func queryXrdb() (string, error) {
+	out, err := exec.Command("xrdb", "-query").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not query resources with xrdb: %w", err)
+	}
+	return string(out), nil
+}
+
+// preprocess runs the Xresources file at path through the C preprocessor,
+// like xrdb does, if it has directives such as #define or #include.
+func preprocess(path string, input string) (string, error) {
+	if !hasDirectives(input) {
+		return input, nil
+	}
+	// -traditional-cpp keeps apostrophes in ! comments from being read as
+	// unterminated character constants, and -undef keeps words like
+	// "linux" in values.
+	cmd := exec.Command("cpp", "-P", "-undef", "-traditional-cpp", path)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", fmt.Errorf(
+			"could not preprocess Xresources file %s with cpp: %w",
+			path,
+			err,
+		)
+	}
+	return string(out), nil
+}
+
+func hasDirectives(input string) bool {
+	for _, line := range strings.Split(input, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadXresources loads the theme of application app from the Xresources file
+// at path, or from the resource database of the running X server if path is
+// XrdbSource. Files with preprocessor directives need cpp to be installed.
+func LoadXresources(path string, app string) (*parsing.Theme, error) {
+	var input string
+	if path == XrdbSource {
+		var err error
+		input, err = queryXrdb()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not read Xresources file %s: %w",
+				path,
+				err,
+			)
+		}
+		input, err = preprocess(path, string(bytes))
+		if err != nil {
+			return nil, err
+		}
+	}
+	res := parsing.ParseXresources(input)
+	return parsing.ThemeFromResources(res, app), nil
+}
+
+// LoadJSON loads a pywal or base16 theme from the JSON file at path.
+func LoadJSON(path string) (*parsing.Theme, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read theme file %s: %w", path, err)
+	}
+	theme, err := parsing.ParseJSONTheme(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not load theme file %s: %w", path, err)
+	}
+	return theme, nil
+}
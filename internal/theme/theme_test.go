@@ -0,0 +1,52 @@
+package theme
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/LinusMB/Notify/internal/parsing"
+)
+
+func TestLoadXresources(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		cpp   bool
+		want  parsing.Theme
+	}{
+		{
+			"plain",
+			"! colors\n*.background: #282828\nnotify.foreground: #ebdbb2\n",
+			false,
+			parsing.Theme{Background: "#282828", Foreground: "#ebdbb2"},
+		},
+		{
+			"define",
+			"#define bg #282828\n! don't use the default\n*.background: bg\n*.color1: red\n",
+			true,
+			parsing.Theme{Background: "#282828", BorderColor: "red"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cpp {
+				if _, err := exec.LookPath("cpp"); err != nil {
+					t.Skip("cpp is not installed")
+				}
+			}
+			path := filepath.Join(t.TempDir(), "Xresources")
+			if err := os.WriteFile(path, []byte(tt.input), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadXresources(path, "notify")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
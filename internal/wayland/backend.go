@@ -0,0 +1,541 @@
+package wayland
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/raster"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/faiface/pixel"
+	"golang.org/x/sys/unix"
+)
+
+// Backend displays notifications as wlr-layer-shell surfaces, which unlike
+// GLFW windows can be positioned on Wayland. The surface is anchored to the
+// corner of the output the position is relative to, with the position as
+// margins.
+type Backend struct{}
+
+func (Backend) Show(
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	c, err := dial()
+	if err != nil {
+		return render.EventNone, err
+	}
+	defer c.Close()
+	return show(c, l, opts)
+}
+
+type global struct {
+	name    uint32
+	version uint32
+}
+
+type client struct {
+	*conn
+	handlers map[uint32]func(msg message, d *decoder) error
+
+	globals map[string]global
+
+	registry     uint32
+	compositor   uint32
+	shm          uint32
+	layerShell   uint32
+	seat         uint32
+	surface      uint32
+	layerSurface uint32
+	pointer      uint32
+	buffer       uint32
+
+	dataDeviceManager uint32
+	dataDevice        uint32
+	dataSource        uint32
+
+	size       image.Point
+	configured bool
+	// pending is the image that is shown once the compositor has
+	// configured the new size of the surface.
+	pending *image.RGBA
+	// layout is the layout that is shown and pointerPos the position of
+	// the pointer on it, for finding the links clicked.
+	layout     *layout.Layout
+	pointerPos pixel.Vec
+	opts       render.Options
+	event      render.Event
+	done       bool
+
+	// restore fires once the feedback of copying has been shown.
+	restore <-chan time.Time
+}
+
+func show(c *conn, l *layout.Layout, opts render.Options) (render.Event, error) {
+	cl := client{
+		conn:     c,
+		handlers: make(map[uint32]func(message, *decoder) error),
+		globals:  make(map[string]global),
+		layout:   l,
+		opts:     opts,
+	}
+	if err := cl.setup(raster.RenderImage(l), opts); err != nil {
+		return render.EventNone, err
+	}
+
+	// Messages are only received in the background and handled here, so
+	// that updates do not race with the handlers.
+	type result struct {
+		msg message
+		err error
+	}
+	results := make(chan result)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			msg, err := cl.receive()
+			select {
+			case results <- result{msg, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	timeout := render.Timeout(opts.Duration)
+	for !cl.done {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				return render.EventNone, r.err
+			}
+			if err := cl.handle(r.msg); err != nil {
+				return render.EventNone, err
+			}
+		case <-cl.restore:
+			cl.restore = nil
+			if err := cl.update(raster.RenderImage(cl.layout)); err != nil {
+				return render.EventNone, err
+			}
+		case <-timeout:
+			return render.EventTimeout, nil
+		case <-opts.Cancel:
+			return render.EventCanceled, nil
+		case n := <-opts.Updates:
+			cl.layout = opts.Relayout(n)
+			if err := cl.update(raster.RenderImage(cl.layout)); err != nil {
+				return render.EventNone, err
+			}
+			timeout = render.Timeout(opts.Duration)
+		}
+	}
+	return cl.event, nil
+}
+
+func (cl *client) dispatch() error {
+	msg, err := cl.receive()
+	if err != nil {
+		return err
+	}
+	return cl.handle(msg)
+}
+
+func (cl *client) handle(msg message) error {
+	if msg.object == displayID {
+		return cl.handleDisplay(msg)
+	}
+	handle, ok := cl.handlers[msg.object]
+	if !ok {
+		return nil
+	}
+	d := cl.decoder(msg)
+	if err := handle(msg, d); err != nil {
+		return err
+	}
+	return d.err
+}
+
+func (cl *client) handleDisplay(msg message) error {
+	d := cl.decoder(msg)
+	switch msg.opcode {
+	case displayError:
+		object, code, text := d.uint(), d.uint(), d.string()
+		return fmt.Errorf(
+			"compositor error on object %d (code %d): %s",
+			object,
+			code,
+			text,
+		)
+	case displayDeleteID:
+		delete(cl.handlers, d.uint())
+	}
+	return d.err
+}
+
+// roundtrip blocks until the compositor has processed all requests sent so
+// far.
+func (cl *client) roundtrip() error {
+	callback := cl.newID()
+	if err := cl.send(displayID, displaySync, func(e *encoder) {
+		e.uint(callback)
+	}); err != nil {
+		return err
+	}
+	var done bool
+	cl.handlers[callback] = func(msg message, d *decoder) error {
+		done = msg.opcode == callbackDone
+		return nil
+	}
+	for !done {
+		if err := cl.dispatch(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cl *client) bind(iface string, version uint32) (uint32, error) {
+	g, ok := cl.globals[iface]
+	if !ok {
+		return 0, fmt.Errorf("compositor does not support %s", iface)
+	}
+	if g.version < version {
+		version = g.version
+	}
+	id := cl.newID()
+	err := cl.send(cl.registry, registryBind, func(e *encoder) {
+		e.uint(g.name)
+		e.string(iface)
+		e.uint(version)
+		e.uint(id)
+	})
+	return id, err
+}
+
+func (cl *client) setup(img *image.RGBA, opts render.Options) error {
+	cl.registry = cl.newID()
+	cl.handlers[cl.registry] = func(msg message, d *decoder) error {
+		if msg.opcode == registryGlobal {
+			name, iface, version := d.uint(), d.string(), d.uint()
+			cl.globals[iface] = global{name, version}
+		}
+		return nil
+	}
+	if err := cl.send(displayID, displayGetRegistry, func(e *encoder) {
+		e.uint(cl.registry)
+	}); err != nil {
+		return err
+	}
+	if err := cl.roundtrip(); err != nil {
+		return err
+	}
+
+	var err error
+	if cl.compositor, err = cl.bind("wl_compositor", 1); err != nil {
+		return err
+	}
+	if cl.shm, err = cl.bind("wl_shm", 1); err != nil {
+		return err
+	}
+	if cl.layerShell, err = cl.bind("zwlr_layer_shell_v1", 1); err != nil {
+		return err
+	}
+	// Without a seat the notification cannot be clicked, but is still shown.
+	if _, ok := cl.globals["wl_seat"]; ok {
+		if cl.seat, err = cl.bind("wl_seat", 1); err != nil {
+			return err
+		}
+		cl.handlers[cl.seat] = cl.handleSeat
+		if err := cl.setupDataDevice(); err != nil {
+			return err
+		}
+	}
+
+	if err := cl.createSurface(img.Bounds().Size(), opts); err != nil {
+		return err
+	}
+	for !cl.configured && !cl.done {
+		if err := cl.dispatch(); err != nil {
+			return err
+		}
+	}
+	if cl.done {
+		return nil
+	}
+	return cl.attach(img)
+}
+
+func (cl *client) createSurface(size image.Point, opts render.Options) error {
+	cl.surface = cl.newID()
+	if err := cl.send(cl.compositor, compositorCreateSurface, func(e *encoder) {
+		e.uint(cl.surface)
+	}); err != nil {
+		return err
+	}
+
+	cl.layerSurface = cl.newID()
+	cl.handlers[cl.layerSurface] = cl.handleLayerSurface
+	if err := cl.send(cl.layerShell, layerShellGetLayerSurface, func(e *encoder) {
+		e.uint(cl.layerSurface)
+		e.uint(cl.surface)
+		e.uint(0) // the compositor chooses the output
+		e.uint(layerOverlay)
+		e.string(opts.Title)
+	}); err != nil {
+		return err
+	}
+
+	// Layer surfaces are shown on all workspaces anyway, so only
+	// click-through needs to be handled: an empty input region passes all
+	// pointer input on to the surfaces below.
+	if opts.ClickThrough {
+		region := cl.newID()
+		if err := cl.send(cl.compositor, compositorCreateRegion, func(e *encoder) {
+			e.uint(region)
+		}); err != nil {
+			return err
+		}
+		if err := cl.send(cl.surface, surfaceSetInputRegion, func(e *encoder) {
+			e.uint(region)
+		}); err != nil {
+			return err
+		}
+	}
+
+	anchor, margin := position(opts.X, opts.Y)
+	if err := cl.setSize(size); err != nil {
+		return err
+	}
+	if err := cl.send(cl.layerSurface, layerSurfaceSetAnchor, func(e *encoder) {
+		e.uint(anchor)
+	}); err != nil {
+		return err
+	}
+	if err := cl.send(cl.layerSurface, layerSurfaceSetMargin, func(e *encoder) {
+		for _, m := range margin {
+			e.int(m)
+		}
+	}); err != nil {
+		return err
+	}
+	// The initial commit without a buffer makes the compositor send the
+	// first configure event.
+	return cl.send(cl.surface, surfaceCommit, nil)
+}
+
+func (cl *client) setSize(size image.Point) error {
+	cl.size = size
+	return cl.send(cl.layerSurface, layerSurfaceSetSize, func(e *encoder) {
+		e.uint(uint32(size.X))
+		e.uint(uint32(size.Y))
+	})
+}
+
+// update shows a new image. If its size changed, the surface is resized
+// first: the new size is committed and the image is attached only once the
+// compositor has configured it, in handleLayerSurface.
+func (cl *client) update(img *image.RGBA) error {
+	if size := img.Bounds().Size(); size != cl.size {
+		if err := cl.setSize(size); err != nil {
+			return err
+		}
+		cl.pending = img
+		return cl.send(cl.surface, surfaceCommit, nil)
+	}
+	if cl.pending != nil {
+		cl.pending = img
+		return nil
+	}
+	return cl.attach(img)
+}
+
+// position converts a window position, where negative values are relative
+// to the bottom right corner, to an anchor and the top, right, bottom and
+// left margins of a layer surface.
+func position(x, y float64) (uint32, [4]int32) {
+	var (
+		anchor uint32
+		margin [4]int32
+	)
+	if y < 0 {
+		anchor |= anchorBottom
+		margin[2] = int32(-y)
+	} else {
+		anchor |= anchorTop
+		margin[0] = int32(y)
+	}
+	if x < 0 {
+		anchor |= anchorRight
+		margin[1] = int32(-x)
+	} else {
+		anchor |= anchorLeft
+		margin[3] = int32(x)
+	}
+	return anchor, margin
+}
+
+// attach copies the image into a shared memory buffer and shows it.
+func (cl *client) attach(img *image.RGBA) error {
+	size := img.Bounds().Size()
+	stride := 4 * size.X
+	data := make([]byte, stride*size.Y)
+	// wl_shm's ARGB8888 is premultiplied and stored in little-endian order,
+	// i.e. as B, G, R, A.
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			c := img.RGBAAt(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+			copy(data[y*stride+4*x:], []byte{c.B, c.G, c.R, c.A})
+		}
+	}
+
+	fd, err := unix.MemfdCreate("notify", unix.MFD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("could not create shared memory: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "notify-shm")
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("could not write shared memory: %w", err)
+	}
+
+	pool := cl.newID()
+	if err := cl.send(cl.shm, shmCreatePool, func(e *encoder) {
+		e.uint(pool)
+		e.fd(fd)
+		e.int(int32(len(data)))
+	}); err != nil {
+		return err
+	}
+	buffer := cl.newID()
+	if err := cl.send(pool, shmPoolCreateBuffer, func(e *encoder) {
+		e.uint(buffer)
+		e.int(0)
+		e.int(int32(size.X))
+		e.int(int32(size.Y))
+		e.int(int32(stride))
+		e.uint(shmFormatARGB8888)
+	}); err != nil {
+		return err
+	}
+	if err := cl.send(pool, shmPoolDestroy, nil); err != nil {
+		return err
+	}
+
+	if err := cl.send(cl.surface, surfaceAttach, func(e *encoder) {
+		e.uint(buffer)
+		e.int(0)
+		e.int(0)
+	}); err != nil {
+		return err
+	}
+
+	if err := cl.send(cl.surface, surfaceDamage, func(e *encoder) {
+		e.int(0)
+		e.int(0)
+		e.int(int32(size.X))
+		e.int(int32(size.Y))
+	}); err != nil {
+		return err
+	}
+	if err := cl.send(cl.surface, surfaceCommit, nil); err != nil {
+		return err
+	}
+	// The previous buffer is replaced by the commit and can be destroyed.
+	previous := cl.buffer
+	cl.buffer = buffer
+	if previous == 0 {
+		return nil
+	}
+	return cl.send(previous, bufferDestroy, nil)
+}
+
+func (cl *client) handleLayerSurface(msg message, d *decoder) error {
+	switch msg.opcode {
+	case layerSurfaceConfigure:
+		serial := d.uint()
+		if err := cl.send(cl.layerSurface, layerSurfaceAck, func(e *encoder) {
+			e.uint(serial)
+		}); err != nil {
+			return err
+		}
+		if !cl.configured {
+			cl.configured = true
+			return nil
+		}
+		if img := cl.pending; img != nil {
+			cl.pending = nil
+			return cl.attach(img)
+		}
+		return cl.send(cl.surface, surfaceCommit, nil)
+	case layerSurfaceClosed:
+		cl.event, cl.done = render.EventClosed, true
+	}
+	return nil
+}
+
+func (cl *client) handleSeat(msg message, d *decoder) error {
+	if msg.opcode != seatCapabilities {
+		return nil
+	}
+	capabilities := d.uint()
+	if capabilities&seatCapabilityPointer == 0 || cl.pointer != 0 {
+		return nil
+	}
+	cl.pointer = cl.newID()
+	cl.handlers[cl.pointer] = cl.handlePointer
+	return cl.send(cl.seat, seatGetPointer, func(e *encoder) {
+		e.uint(cl.pointer)
+	})
+}
+
+func (cl *client) handlePointer(msg message, d *decoder) error {
+	switch msg.opcode {
+	case pointerEnter:
+		_, _ = d.uint(), d.uint()
+		cl.setPointerPos(d.int(), d.int())
+	case pointerMotion:
+		_ = d.uint()
+		cl.setPointerPos(d.int(), d.int())
+	case pointerButton:
+		serial, _, button, state := d.uint(), d.uint(), d.uint(), d.uint()
+		if state != pointerButtonPressed {
+			return nil
+		}
+		switch button {
+		case btnMiddle:
+			if cl.opts.Copy == nil {
+				cl.event, cl.done = render.EventMiddleClick, true
+				return nil
+			}
+			if err := cl.copy(cl.opts.Copy(), serial); err != nil {
+				log.Printf("warning: could not copy text: %v", err)
+				return nil
+			}
+			if err := cl.update(raster.RenderImage(cl.layout.Inverted())); err != nil {
+				return err
+			}
+			cl.restore = time.After(render.FeedbackDuration)
+		case btnLeft:
+			if !render.ClickLink(cl.layout, cl.opts, cl.pointerPos) {
+				cl.event, cl.done = render.EventLeftClick, true
+			}
+		case btnRight:
+			cl.event, cl.done = render.EventRightClick, true
+		}
+	}
+	return nil
+}
+
+// setPointerPos converts surface coordinates, which are fixed-point numbers
+// with 8 fractional bits and have their origin in the top left corner, to
+// layout coordinates.
+func (cl *client) setPointerPos(x, y int32) {
+	cl.pointerPos = pixel.V(float64(x)/256, float64(cl.size.Y)-float64(y)/256)
+}
@@ -0,0 +1,543 @@
+package wayland
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/faiface/pixel"
+	"golang.org/x/sys/unix"
+)
+
+func socketPair(t *testing.T) (*conn, *conn) {
+	t.Helper()
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toConn := func(fd int) *conn {
+		file := os.NewFile(uintptr(fd), "wayland")
+		defer file.Close()
+		fc, err := net.FileConn(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newConn(fc.(*net.UnixConn))
+	}
+	return toConn(fds[0]), toConn(fds[1])
+}
+
+var defaultGlobals = []string{
+	"wl_compositor",
+	"wl_shm",
+	"zwlr_layer_shell_v1",
+	"wl_seat",
+}
+
+// stubCompositor implements just enough of a compositor to display a
+// single layer surface. Once a buffer is committed, it calls onShow.
+type stubCompositor struct {
+	c       *conn
+	globals []string
+	onShow  func(s *stubCompositor)
+
+	objects map[uint32]string
+	pointer uint32
+	layer   uint32
+	surface uint32
+
+	namespace string
+	layerType uint32
+	size      [2]uint32
+	anchor    uint32
+	margin    [4]int32
+	acked     uint32
+	// configured is the size and configure the serial of the last
+	// configure event.
+	configured [2]uint32
+	configure  uint32
+	pool       []byte
+	buffer     [5]int32
+	attached   bool
+	input      uint32
+	buffers    []uint32
+	destroyed  []uint32
+	offers     []string
+	serial     uint32
+	pasted     string
+	err        error
+}
+
+func (s *stubCompositor) serve() {
+	s.objects = map[uint32]string{displayID: "wl_display"}
+	for {
+		msg, err := s.c.receive()
+		if err != nil {
+			return
+		}
+		if s.objects[msg.object] == "wl_buffer" && msg.opcode == bufferDestroy {
+			s.destroyed = append(s.destroyed, msg.object)
+		}
+		d := s.c.decoder(msg)
+		s.handle(s.objects[msg.object], msg.opcode, d)
+		if d.err != nil {
+			s.err = d.err
+		}
+	}
+}
+
+func (s *stubCompositor) handle(iface string, opcode uint16, d *decoder) {
+	send := func(object uint32, opcode uint16, args ...uint32) {
+		s.c.send(object, opcode, func(e *encoder) {
+			for _, a := range args {
+				e.uint(a)
+			}
+		})
+	}
+	switch {
+	case iface == "wl_display" && opcode == displayGetRegistry:
+		id := d.uint()
+		s.objects[id] = "wl_registry"
+		for i, g := range s.globals {
+			s.c.send(id, registryGlobal, func(e *encoder) {
+				e.uint(uint32(i + 1))
+				e.string(g)
+				e.uint(4)
+			})
+		}
+	case iface == "wl_display" && opcode == displaySync:
+		id := d.uint()
+		send(id, callbackDone, 0)
+		send(displayID, displayDeleteID, id)
+	case iface == "wl_registry" && opcode == registryBind:
+		_, name, _, id := d.uint(), d.string(), d.uint(), d.uint()
+		s.objects[id] = name
+		if name == "wl_seat" {
+			send(id, seatCapabilities, seatCapabilityPointer)
+		}
+	case iface == "wl_seat" && opcode == seatGetPointer:
+		s.pointer = d.uint()
+		s.objects[s.pointer] = "wl_pointer"
+	case iface == "wl_data_device_manager" && opcode == dataDeviceManagerCreateDataSource:
+		s.objects[d.uint()] = "wl_data_source"
+	case iface == "wl_data_device_manager" && opcode == dataDeviceManagerGetDataDevice:
+		s.objects[d.uint()] = "wl_data_device"
+		d.uint()
+	case iface == "wl_data_source" && opcode == dataSourceOffer:
+		s.offers = append(s.offers, d.string())
+	case iface == "wl_data_device" && opcode == dataDeviceSetSelection:
+		source := d.uint()
+		s.serial = d.uint()
+		s.pasted = s.paste(source)
+	case iface == "wl_compositor" && opcode == compositorCreateSurface:
+		s.surface = d.uint()
+		s.objects[s.surface] = "wl_surface"
+	case iface == "zwlr_layer_shell_v1" && opcode == layerShellGetLayerSurface:
+		s.layer = d.uint()
+		s.objects[s.layer] = "zwlr_layer_surface_v1"
+		d.uint()
+		d.uint()
+		s.layerType = d.uint()
+		s.namespace = d.string()
+	case iface == "zwlr_layer_surface_v1" && opcode == layerSurfaceSetSize:
+		s.size = [2]uint32{d.uint(), d.uint()}
+	case iface == "zwlr_layer_surface_v1" && opcode == layerSurfaceSetAnchor:
+		s.anchor = d.uint()
+	case iface == "zwlr_layer_surface_v1" && opcode == layerSurfaceSetMargin:
+		s.margin = [4]int32{d.int(), d.int(), d.int(), d.int()}
+	case iface == "zwlr_layer_surface_v1" && opcode == layerSurfaceAck:
+		s.acked = d.uint()
+	case iface == "wl_shm" && opcode == shmCreatePool:
+		id, fd, size := d.uint(), d.fd(), d.int()
+		s.objects[id] = "wl_shm_pool"
+		s.pool = make([]byte, size)
+		unix.Pread(fd, s.pool, 0)
+		unix.Close(fd)
+	case iface == "wl_shm_pool" && opcode == shmPoolCreateBuffer:
+		id := d.uint()
+		s.objects[id] = "wl_buffer"
+		s.buffers = append(s.buffers, id)
+		for i := range s.buffer {
+			s.buffer[i] = d.int()
+		}
+	case iface == "wl_compositor" && opcode == compositorCreateRegion:
+		s.objects[d.uint()] = "wl_region"
+	case iface == "wl_surface" && opcode == surfaceSetInputRegion:
+		s.input = d.uint()
+	case iface == "wl_surface" && opcode == surfaceAttach:
+		s.attached = d.uint() != 0
+	case iface == "wl_surface" && opcode == surfaceCommit:
+		if s.attached && (s.acked != s.configure ||
+			[2]uint32{uint32(s.buffer[1]), uint32(s.buffer[2])} != s.configured) {
+			s.err = fmt.Errorf(
+				"buffer of %dx%d committed with configure %d of %v acked as %d",
+				s.buffer[1], s.buffer[2], s.configure, s.configured, s.acked,
+			)
+		}
+		if !s.attached || s.size != s.configured {
+			s.configured = s.size
+			if s.configure == 0 {
+				s.configure = 7
+			} else {
+				s.configure++
+			}
+			send(s.layer, layerSurfaceConfigure, s.configure, 0, 0)
+		} else if s.onShow != nil {
+			s.onShow(s)
+		}
+	}
+}
+
+func (s *stubCompositor) click(button uint32) {
+	s.c.send(s.pointer, pointerButton, func(e *encoder) {
+		e.uint(1)
+		e.uint(0)
+		e.uint(button)
+		e.uint(pointerButtonPressed)
+	})
+}
+
+// paste reads the text of the data source like a client pasting it.
+func (s *stubCompositor) paste(source uint32) string {
+	var fds [2]int
+	if err := unix.Pipe2(fds[:], unix.O_CLOEXEC); err != nil {
+		s.err = err
+		return ""
+	}
+	s.c.send(source, dataSourceSend, func(e *encoder) {
+		e.string(s.offers[0])
+		e.fd(fds[1])
+	})
+	unix.Close(fds[1])
+	r := os.NewFile(uintptr(fds[0]), "paste")
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		s.err = err
+	}
+	return string(b)
+}
+
+// moveTo moves the pointer to x and y in surface coordinates.
+func (s *stubCompositor) moveTo(x, y float64) {
+	s.c.send(s.pointer, pointerMotion, func(e *encoder) {
+		e.uint(0)
+		e.int(int32(x * 256))
+		e.int(int32(y * 256))
+	})
+}
+
+func runStub(
+	t *testing.T,
+	s *stubCompositor,
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	t.Helper()
+	client, server := socketPair(t)
+	s.c = server
+	if s.globals == nil {
+		s.globals = defaultGlobals
+	}
+	done := make(chan struct{})
+	go func() {
+		s.serve()
+		close(done)
+	}()
+	ev, err := show(client, l, opts)
+	client.Close()
+	<-done
+	server.Close()
+	if s.err != nil {
+		t.Fatalf("malformed request: %v", s.err)
+	}
+	return ev, err
+}
+
+// testLayout is a layout of two pixels, an opaque red and a translucent
+// blue one.
+func testLayout() *layout.Layout {
+	return &layout.Layout{
+		Width:  2,
+		Height: 1,
+		Border: []layout.Box{
+			{Rect: pixel.R(0, 0, 1, 1), Color: color.NRGBA{R: 0xff, A: 0xff}},
+			{Rect: pixel.R(1, 0, 2, 1), Color: color.NRGBA{B: 0xff, A: 0x80}},
+		},
+		Opacity: 1,
+	}
+}
+
+func TestShow(t *testing.T) {
+	s := stubCompositor{
+		onShow: func(s *stubCompositor) { s.click(btnRight) },
+	}
+	ev, err := runStub(t, &s, testLayout(), render.Options{
+		Title: "notify",
+		X:     -10,
+		Y:     20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != render.EventRightClick {
+		t.Errorf("got event %v, want %v", ev, render.EventRightClick)
+	}
+	if s.namespace != "notify" || s.layerType != layerOverlay {
+		t.Errorf("got namespace %q on layer %d", s.namespace, s.layerType)
+	}
+	if s.size != [2]uint32{2, 1} {
+		t.Errorf("got size %v, want [2 1]", s.size)
+	}
+	if want := uint32(anchorTop | anchorRight); s.anchor != want {
+		t.Errorf("got anchor %d, want %d", s.anchor, want)
+	}
+	if want := [4]int32{20, 10, 0, 0}; s.margin != want {
+		t.Errorf("got margin %v, want %v", s.margin, want)
+	}
+	if s.acked != 7 {
+		t.Errorf("got acked serial %d, want 7", s.acked)
+	}
+	if want := [5]int32{0, 2, 1, 8, shmFormatARGB8888}; s.buffer != want {
+		t.Errorf("got buffer %v, want %v", s.buffer, want)
+	}
+	if want := []byte{0, 0, 0xff, 0xff, 0x80, 0, 0, 0x80}; !bytes.Equal(s.pool, want) {
+		t.Errorf("got pixels %v, want %v", s.pool, want)
+	}
+}
+
+func TestShow_Events(t *testing.T) {
+	tests := []struct {
+		name     string
+		onShow   func(s *stubCompositor)
+		duration time.Duration
+		want     render.Event
+	}{
+		{
+			"left click",
+			func(s *stubCompositor) { s.click(btnLeft) },
+			0,
+			render.EventLeftClick,
+		},
+		{
+			"middle click",
+			func(s *stubCompositor) { s.click(btnMiddle) },
+			0,
+			render.EventMiddleClick,
+		},
+		{
+			"closed",
+			func(s *stubCompositor) {
+				s.c.send(s.layer, layerSurfaceClosed, nil)
+			},
+			0,
+			render.EventClosed,
+		},
+		{
+			"timeout",
+			nil,
+			10 * time.Millisecond,
+			render.EventTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := stubCompositor{onShow: tt.onShow}
+			ev, err := runStub(t, &s, testLayout(), render.Options{
+				Duration: tt.duration,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ev != tt.want {
+				t.Errorf("got event %v, want %v", ev, tt.want)
+			}
+		})
+	}
+}
+
+func TestShow_Update(t *testing.T) {
+	updates := make(chan *parsing.Notification)
+	var shown int
+	s := stubCompositor{
+		onShow: func(s *stubCompositor) {
+			shown++
+			if shown == 1 {
+				go func() { updates <- &parsing.Notification{Body: "updated"} }()
+			} else {
+				s.click(btnLeft)
+			}
+		},
+	}
+	ev, err := runStub(t, &s, testLayout(), render.Options{
+		Updates: updates,
+		Relayout: func(n *parsing.Notification) *layout.Layout {
+			return &layout.Layout{
+				Width:   3,
+				Height:  2,
+				Content: layout.Box{Rect: pixel.R(0, 0, 3, 2), Color: color.White},
+				Opacity: 1,
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != render.EventLeftClick {
+		t.Errorf("got event %v, want %v", ev, render.EventLeftClick)
+	}
+	if s.size != [2]uint32{3, 2} {
+		t.Errorf("got size %v, want [3 2]", s.size)
+	}
+	if want := [5]int32{0, 3, 2, 12, shmFormatARGB8888}; s.buffer != want {
+		t.Errorf("got buffer %v, want %v", s.buffer, want)
+	}
+	if len(s.buffers) != 2 || len(s.destroyed) != 1 || s.destroyed[0] != s.buffers[0] {
+		t.Errorf("got destroyed buffers %v of %v, want the first", s.destroyed, s.buffers)
+	}
+}
+
+func TestShow_Link(t *testing.T) {
+	fs, err := ifont.LoadOpentypeFontSetDefault(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := layout.New(
+		layout.Config{Fonts: fs, Foreground: color.White, Opacity: 1},
+		&parsing.Notification{Body: "see https://example.com"},
+	)
+	link := l.Text[0].Links[0].Bounds.Center()
+
+	var opened []string
+	s := stubCompositor{
+		onShow: func(s *stubCompositor) {
+			s.moveTo(link.X, l.Height-link.Y)
+			s.click(btnLeft)
+			s.moveTo(1, 1)
+			s.click(btnLeft)
+		},
+	}
+	ev, err := runStub(t, &s, l, render.Options{
+		OpenLink: func(url string) { opened = append(opened, url) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != render.EventLeftClick {
+		t.Errorf("got event %v, want %v", ev, render.EventLeftClick)
+	}
+	if len(opened) != 1 || opened[0] != "https://example.com" {
+		t.Errorf("got opened links %v, want https://example.com", opened)
+	}
+}
+
+func TestShow_Copy(t *testing.T) {
+	var shown int
+	s := stubCompositor{
+		globals: append(defaultGlobals, "wl_data_device_manager"),
+		onShow: func(s *stubCompositor) {
+			shown++
+			switch shown {
+			case 1:
+				s.click(btnMiddle)
+			case 3:
+				// The notification is restored after the feedback.
+				s.click(btnRight)
+			}
+		},
+	}
+	ev, err := runStub(t, &s, testLayout(), render.Options{
+		Copy: func() string { return "copied text" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != render.EventRightClick {
+		t.Errorf("got event %v, want %v", ev, render.EventRightClick)
+	}
+	if s.pasted != "copied text" || s.serial != 1 {
+		t.Errorf("got pasted text %q with serial %d, want %q with serial 1", s.pasted, s.serial, "copied text")
+	}
+	if len(s.offers) == 0 || s.offers[0] != "text/plain;charset=utf-8" {
+		t.Errorf("got offered types %v", s.offers)
+	}
+}
+
+func TestShow_CopyUnsupported(t *testing.T) {
+	var shown int
+	s := stubCompositor{
+		onShow: func(s *stubCompositor) {
+			shown++
+			s.click(btnMiddle)
+			s.click(btnRight)
+		},
+	}
+	ev, err := runStub(t, &s, testLayout(), render.Options{
+		Copy: func() string { return "copied text" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != render.EventRightClick {
+		t.Errorf("got event %v, want %v", ev, render.EventRightClick)
+	}
+	if shown != 1 {
+		t.Errorf("notification was shown %d times, want no feedback for a failed copy", shown)
+	}
+}
+
+func TestShow_ClickThrough(t *testing.T) {
+	s := stubCompositor{}
+	ev, err := runStub(t, &s, testLayout(), render.Options{
+		Duration:     10 * time.Millisecond,
+		ClickThrough: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != render.EventTimeout {
+		t.Errorf("got event %v, want %v", ev, render.EventTimeout)
+	}
+	if s.objects[s.input] != "wl_region" {
+		t.Errorf("want empty input region, got object %d", s.input)
+	}
+}
+
+func TestShow_MissingLayerShell(t *testing.T) {
+	s := stubCompositor{globals: []string{"wl_compositor", "wl_shm"}}
+	_, err := runStub(t, &s, testLayout(), render.Options{})
+	if err == nil || !strings.Contains(err.Error(), "zwlr_layer_shell_v1") {
+		t.Errorf("got error %v, want unsupported zwlr_layer_shell_v1", err)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		x, y   float64
+		anchor uint32
+		margin [4]int32
+	}{
+		{20, 20, anchorTop | anchorLeft, [4]int32{20, 0, 0, 20}},
+		{-5, 0, anchorTop | anchorRight, [4]int32{0, 5, 0, 0}},
+		{0, -15, anchorBottom | anchorLeft, [4]int32{0, 0, 15, 0}},
+	}
+	for _, tt := range tests {
+		anchor, margin := position(tt.x, tt.y)
+		if anchor != tt.anchor || margin != tt.margin {
+			t.Errorf(
+				"position(%v, %v) = %d, %v, want %d, %v",
+				tt.x, tt.y, anchor, margin, tt.anchor, tt.margin,
+			)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package wayland
+
+import (
+	"errors"
+	"os"
+)
+
+// mimeTypes are the types the copied text is offered as.
+var mimeTypes = []string{
+	"text/plain;charset=utf-8",
+	"text/plain",
+	"UTF8_STRING",
+}
+
+// setupDataDevice gets the data device of the seat, through which text is
+// copied. Without a data device manager, copying fails.
+func (cl *client) setupDataDevice() error {
+	if _, ok := cl.globals["wl_data_device_manager"]; !ok {
+		return nil
+	}
+	var err error
+	if cl.dataDeviceManager, err = cl.bind("wl_data_device_manager", 1); err != nil {
+		return err
+	}
+	cl.dataDevice = cl.newID()
+	return cl.send(cl.dataDeviceManager, dataDeviceManagerGetDataDevice, func(e *encoder) {
+		e.uint(cl.dataDevice)
+		e.uint(cl.seat)
+	})
+}
+
+// copy offers text as the selection of the seat, with the serial of the
+// click that copied it. The text is lost when the notification closes,
+// unless a clipboard manager takes it over.
+func (cl *client) copy(text string, serial uint32) error {
+	if cl.dataDevice == 0 {
+		return errors.New("the compositor does not support the clipboard")
+	}
+	if err := cl.destroyDataSource(); err != nil {
+		return err
+	}
+
+	source := cl.newID()
+	cl.handlers[source] = func(msg message, d *decoder) error {
+		switch msg.opcode {
+		case dataSourceSend:
+			_, fd := d.string(), d.fd()
+			if d.err != nil {
+				return nil
+			}
+			// The paste may be read slowly, so that the text is written
+			// in the background.
+			file := os.NewFile(uintptr(fd), "clipboard")
+			go func() {
+				file.WriteString(text)
+				file.Close()
+			}()
+		case dataSourceCancelled:
+			// Another client took over the selection.
+			return cl.destroyDataSource()
+		}
+		return nil
+	}
+	if err := cl.send(cl.dataDeviceManager, dataDeviceManagerCreateDataSource, func(e *encoder) {
+		e.uint(source)
+	}); err != nil {
+		return err
+	}
+	for _, mime := range mimeTypes {
+		if err := cl.send(source, dataSourceOffer, func(e *encoder) {
+			e.string(mime)
+		}); err != nil {
+			return err
+		}
+	}
+	cl.dataSource = source
+	return cl.send(cl.dataDevice, dataDeviceSetSelection, func(e *encoder) {
+		e.uint(source)
+		e.uint(serial)
+	})
+}
+
+func (cl *client) destroyDataSource() error {
+	if cl.dataSource == 0 {
+		return nil
+	}
+	source := cl.dataSource
+	cl.dataSource = 0
+	delete(cl.handlers, source)
+	return cl.send(source, dataSourceDestroy, nil)
+}
@@ -0,0 +1,104 @@
+package wayland
+
+// Opcodes of the requests and events used from the core protocol
+// (wayland.xml) and from wlr-layer-shell-unstable-v1.xml.
+
+const displayID = 1
+
+const (
+	displaySync        = 0
+	displayGetRegistry = 1
+
+	displayError    = 0
+	displayDeleteID = 1
+)
+
+const (
+	registryBind = 0
+
+	registryGlobal = 0
+)
+
+const callbackDone = 0
+
+const (
+	compositorCreateSurface = 0
+	compositorCreateRegion  = 1
+)
+
+const (
+	shmCreatePool = 0
+
+	shmFormatARGB8888 = 0
+)
+
+const (
+	shmPoolCreateBuffer = 0
+	shmPoolDestroy      = 1
+)
+
+const bufferDestroy = 0
+
+const (
+	surfaceAttach         = 1
+	surfaceDamage         = 2
+	surfaceSetInputRegion = 5
+	surfaceCommit         = 6
+)
+
+const (
+	seatGetPointer = 0
+
+	seatCapabilities = 0
+
+	seatCapabilityPointer = 1
+)
+
+const (
+	pointerEnter  = 0
+	pointerMotion = 2
+	pointerButton = 3
+
+	pointerButtonPressed = 1
+
+	// Linux input event codes of mouse buttons.
+	btnLeft   = 0x110
+	btnRight  = 0x111
+	btnMiddle = 0x112
+)
+
+const (
+	dataDeviceManagerCreateDataSource = 0
+	dataDeviceManagerGetDataDevice    = 1
+)
+
+const (
+	dataSourceOffer   = 0
+	dataSourceDestroy = 1
+
+	dataSourceSend      = 1
+	dataSourceCancelled = 2
+)
+
+const dataDeviceSetSelection = 1
+
+const (
+	layerShellGetLayerSurface = 0
+
+	layerOverlay = 3
+)
+
+const (
+	layerSurfaceSetSize   = 0
+	layerSurfaceSetAnchor = 1
+	layerSurfaceSetMargin = 3
+	layerSurfaceAck       = 6
+
+	layerSurfaceConfigure = 0
+	layerSurfaceClosed    = 1
+
+	anchorTop    = 1
+	anchorBottom = 2
+	anchorLeft   = 4
+	anchorRight  = 8
+)
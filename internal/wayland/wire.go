@@ -0,0 +1,251 @@
+package wayland
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The Wayland wire protocol consists of messages with an 8 byte header,
+// holding the object id and the opcode and size of the message, followed by
+// 32-bit aligned arguments in host byte order. File descriptors are passed
+// out of band as SCM_RIGHTS.
+
+const headerSize = 8
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+var order byteOrder = binary.LittleEndian
+
+func init() {
+	one := uint16(1)
+	if *(*byte)(unsafe.Pointer(&one)) == 0 {
+		order = binary.BigEndian
+	}
+}
+
+type message struct {
+	object uint32
+	opcode uint16
+	args   []byte
+}
+
+type encoder struct {
+	buf []byte
+	fds []int
+}
+
+func (e *encoder) uint(v uint32) {
+	e.buf = order.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) int(v int32) {
+	e.uint(uint32(v))
+}
+
+func (e *encoder) string(s string) {
+	e.uint(uint32(len(s) + 1))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+	e.pad()
+}
+
+func (e *encoder) array(b []byte) {
+	e.uint(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+	e.pad()
+}
+
+func (e *encoder) fd(fd int) {
+	e.fds = append(e.fds, fd)
+}
+
+func (e *encoder) pad() {
+	for len(e.buf)%4 != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+type decoder struct {
+	args []byte
+	conn *conn
+	err  error
+}
+
+func (d *decoder) uint() uint32 {
+	if len(d.args) < 4 {
+		d.err = errors.New("message too short")
+		return 0
+	}
+	v := order.Uint32(d.args)
+	d.args = d.args[4:]
+	return v
+}
+
+func (d *decoder) int() int32 {
+	return int32(d.uint())
+}
+
+func (d *decoder) array() []byte {
+	n := int(d.uint())
+	padded := (n + 3) &^ 3
+	if d.err != nil || len(d.args) < padded {
+		d.err = errors.New("message too short")
+		return nil
+	}
+	b := d.args[:n]
+	d.args = d.args[padded:]
+	return b
+}
+
+func (d *decoder) string() string {
+	b := d.array()
+	if len(b) == 0 {
+		return ""
+	}
+	return string(b[:len(b)-1])
+}
+
+func (d *decoder) fd() int {
+	if len(d.conn.fds) == 0 {
+		d.err = errors.New("missing file descriptor")
+		return -1
+	}
+	fd := d.conn.fds[0]
+	d.conn.fds = d.conn.fds[1:]
+	return fd
+}
+
+// A conn sends and receives messages over a Wayland socket. It is used for
+// both ends of the connection so that tests can stub the compositor.
+type conn struct {
+	uc     *net.UnixConn
+	nextID uint32
+	buf    []byte
+	fds    []int
+}
+
+func newConn(uc *net.UnixConn) *conn {
+	// Object id 1 is the wl_display singleton.
+	return &conn{uc: uc, nextID: 2}
+}
+
+// socketPath returns the path of the compositor socket as described in
+// wl_display_connect(3).
+func socketPath() (string, error) {
+	name := os.Getenv("WAYLAND_DISPLAY")
+	if name == "" {
+		name = "wayland-0"
+	}
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return "", errors.New("XDG_RUNTIME_DIR is not set")
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func dial() (*conn, error) {
+	path, err := socketPath()
+	if err != nil {
+		return nil, err
+	}
+	uc, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to compositor: %w", err)
+	}
+	return newConn(uc), nil
+}
+
+func (c *conn) Close() error {
+	for _, fd := range c.fds {
+		unix.Close(fd)
+	}
+	c.fds = nil
+	return c.uc.Close()
+}
+
+func (c *conn) newID() uint32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+func (c *conn) send(object uint32, opcode uint16, args func(e *encoder)) error {
+	var e encoder
+	if args != nil {
+		args(&e)
+	}
+	size := headerSize + len(e.buf)
+	msg := make([]byte, headerSize, size)
+	order.PutUint32(msg, object)
+	order.PutUint32(msg[4:], uint32(size)<<16|uint32(opcode))
+	msg = append(msg, e.buf...)
+
+	var oob []byte
+	if len(e.fds) > 0 {
+		oob = unix.UnixRights(e.fds...)
+	}
+	if _, _, err := c.uc.WriteMsgUnix(msg, oob, nil); err != nil {
+		return fmt.Errorf("could not send message: %w", err)
+	}
+	return nil
+}
+
+func (c *conn) receive() (message, error) {
+	for {
+		if len(c.buf) >= headerSize {
+			size := int(order.Uint32(c.buf[4:]) >> 16)
+			if size < headerSize {
+				return message{}, fmt.Errorf("invalid message size %d", size)
+			}
+			if len(c.buf) >= size {
+				msg := message{
+					object: order.Uint32(c.buf),
+					opcode: uint16(order.Uint32(c.buf[4:])),
+					args:   append([]byte(nil), c.buf[headerSize:size]...),
+				}
+				c.buf = c.buf[size:]
+				return msg, nil
+			}
+		}
+
+		buf := make([]byte, 4096)
+		oob := make([]byte, unix.CmsgSpace(28*4))
+		n, oobn, _, _, err := c.uc.ReadMsgUnix(buf, oob)
+		if err != nil {
+			return message{}, fmt.Errorf("could not receive message: %w", err)
+		}
+		if n == 0 {
+			return message{}, errors.New("connection closed")
+		}
+		c.buf = append(c.buf, buf[:n]...)
+		if oobn > 0 {
+			scms, err := unix.ParseSocketControlMessage(oob[:oobn])
+			if err != nil {
+				return message{}, err
+			}
+			for _, scm := range scms {
+				fds, err := unix.ParseUnixRights(&scm)
+				if err == nil {
+					c.fds = append(c.fds, fds...)
+				}
+			}
+		}
+	}
+}
+
+func (c *conn) decoder(msg message) *decoder {
+	return &decoder{args: msg.args, conn: c}
+}
@@ -0,0 +1,360 @@
+package x11
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/ewmh"
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/raster"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/faiface/pixel"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Backend displays notifications in an override-redirect X11 window that is
+// rendered in software, for displays without working OpenGL.
+type Backend struct{}
+
+func (Backend) Show(
+	l *layout.Layout,
+	opts render.Options,
+) (render.Event, error) {
+	c, err := xgb.NewConn()
+	if err != nil {
+		return render.EventNone, fmt.Errorf("could not connect to X server: %w", err)
+	}
+	defer c.Close()
+
+	w, err := newWindow(c, l, opts)
+	if err != nil {
+		return render.EventNone, err
+	}
+	return w.run(opts)
+}
+
+type window struct {
+	c      *xgb.Conn
+	screen *xproto.ScreenInfo
+	id     xproto.Window
+	gc     xproto.Gcontext
+	depth  byte
+	format pixelFormat
+	// opaque is set if the visual has no alpha channel.
+	opaque bool
+	data   []byte
+	width  int
+	height int
+	// layout is the layout that is shown, for finding the links clicked.
+	layout    *layout.Layout
+	clipboard *clipboard
+}
+
+func newWindow(
+	c *xgb.Conn,
+	l *layout.Layout,
+	opts render.Options,
+) (*window, error) {
+	setup := xproto.Setup(c)
+	screen := setup.DefaultScreen(c)
+
+	var (
+		vis visual
+		err error
+	)
+	if l.IsTranslucent() {
+		var ok bool
+		ok, err = ewmh.Compositing(c)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			vis, err = findVisual(setup, screen, 32)
+		}
+		if !ok || err != nil {
+			log.Print(
+				"warning: transparency is not supported (is a compositor running?), " +
+					"falling back to opaque colors",
+			)
+			l = l.Opaque()
+		}
+	}
+	if !l.IsTranslucent() {
+		vis, err = findVisual(setup, screen, screen.RootDepth)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported root visual: %w", err)
+		}
+	}
+
+	img := raster.RenderImage(l)
+	w := window{
+		c:      c,
+		screen: screen,
+		depth:  vis.depth,
+		format: vis.format,
+		opaque: !l.IsTranslucent(),
+		data:   encodeImage(img, vis.format),
+		width:  img.Bounds().Dx(),
+		height: img.Bounds().Dy(),
+		layout: l,
+	}
+	if w.id, err = xproto.NewWindowId(c); err != nil {
+		return nil, err
+	}
+
+	colormap := screen.DefaultColormap
+	if vis.depth != screen.RootDepth {
+		if colormap, err = xproto.NewColormapId(c); err != nil {
+			return nil, err
+		}
+		if err := xproto.CreateColormapChecked(
+			c,
+			xproto.ColormapAllocNone,
+			colormap,
+			screen.Root,
+			vis.id,
+		).Check(); err != nil {
+			return nil, fmt.Errorf("could not create colormap: %w", err)
+		}
+	}
+
+	eventMask := uint32(xproto.EventMaskExposure | xproto.EventMaskStructureNotify)
+	if !opts.ClickThrough {
+		eventMask |= xproto.EventMaskButtonPress
+	}
+
+	x, y := w.position(opts)
+	if err := xproto.CreateWindowChecked(
+		c,
+		vis.depth,
+		w.id,
+		screen.Root,
+		x,
+		y,
+		uint16(w.width),
+		uint16(w.height),
+		0,
+		xproto.WindowClassInputOutput,
+		vis.id,
+		xproto.CwBackPixel|xproto.CwBorderPixel|xproto.CwOverrideRedirect|
+			xproto.CwEventMask|xproto.CwColormap,
+		[]uint32{
+			0,
+			0,
+			1,
+			eventMask,
+			uint32(colormap),
+		},
+	).Check(); err != nil {
+		return nil, fmt.Errorf("could not create window: %w", err)
+	}
+	if err := ewmh.SetNotificationHints(
+		c,
+		w.id,
+		opts.Title,
+		opts.Sticky,
+	); err != nil {
+		return nil, err
+	}
+	if opts.ClickThrough {
+		if err := ewmh.SetClickThrough(c, w.id); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.gc, err = xproto.NewGcontextId(c); err != nil {
+		return nil, err
+	}
+	if err := xproto.CreateGCChecked(
+		c,
+		w.gc,
+		xproto.Drawable(w.id),
+		0,
+		nil,
+	).Check(); err != nil {
+		return nil, fmt.Errorf("could not create graphics context: %w", err)
+	}
+	if err := xproto.MapWindowChecked(c, w.id).Check(); err != nil {
+		return nil, fmt.Errorf("could not map window: %w", err)
+	}
+	return &w, nil
+}
+
+// position returns the top left corner of the window, where negative
+// coordinates are relative to the bottom right corner of the screen.
+func (w *window) position(opts render.Options) (int16, int16) {
+	x, y := opts.X, opts.Y
+	if x < 0 {
+		x += float64(w.screen.WidthInPixels) - float64(w.width)
+	}
+	if y < 0 {
+		y += float64(w.screen.HeightInPixels) - float64(w.height)
+	}
+	return int16(x), int16(y)
+}
+
+// update replaces the image of the window with the layout, resizing the
+// window if the size of the layout changed.
+func (w *window) update(l *layout.Layout, opts render.Options) error {
+	w.layout = l
+	if w.opaque {
+		l = l.Opaque()
+	}
+	img := raster.RenderImage(l)
+	w.data = encodeImage(img, w.format)
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width != w.width || height != w.height {
+		w.width, w.height = width, height
+		x, y := w.position(opts)
+		if err := xproto.ConfigureWindowChecked(
+			w.c,
+			w.id,
+			xproto.ConfigWindowX|xproto.ConfigWindowY|
+				xproto.ConfigWindowWidth|xproto.ConfigWindowHeight,
+			[]uint32{
+				uint32(int32(x)),
+				uint32(int32(y)),
+				uint32(width),
+				uint32(height),
+			},
+		).Check(); err != nil {
+			return fmt.Errorf("could not resize window: %w", err)
+		}
+	}
+	return w.draw()
+}
+
+// draw puts the image into the window, split into strips that fit into the
+// maximum request length.
+func (w *window) draw() error {
+	stride := 4 * w.width
+	maxLength := 4 * int(xproto.Setup(w.c).MaximumRequestLength)
+	rows := stripRows(stride, maxLength)
+	for y := 0; y < w.height; y += rows {
+		h := rows
+		if y+h > w.height {
+			h = w.height - y
+		}
+		if err := xproto.PutImageChecked(
+			w.c,
+			xproto.ImageFormatZPixmap,
+			xproto.Drawable(w.id),
+			w.gc,
+			uint16(w.width),
+			uint16(h),
+			0,
+			int16(y),
+			0,
+			w.depth,
+			w.data[y*stride:(y+h)*stride],
+		).Check(); err != nil {
+			return fmt.Errorf("could not put image: %w", err)
+		}
+	}
+	return nil
+}
+
+// copy puts text into the clipboard at time t.
+func (w *window) copy(text string, t xproto.Timestamp) error {
+	if w.clipboard == nil {
+		cb, err := newClipboard(w.c, w.id)
+		if err != nil {
+			return err
+		}
+		w.clipboard = cb
+	}
+	return w.clipboard.set(text, t)
+}
+
+func (w *window) run(opts render.Options) (render.Event, error) {
+	type result struct {
+		ev  xgb.Event
+		err error
+	}
+	events := make(chan result)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			ev, err := w.c.WaitForEvent()
+			if ev == nil && err == nil {
+				return
+			}
+			select {
+			case events <- result{ev, err}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	timeout := render.Timeout(opts.Duration)
+	var restore <-chan time.Time
+	for {
+		select {
+		case r := <-events:
+			if r.err != nil {
+				return render.EventNone, fmt.Errorf("X error: %w", r.err)
+			}
+			switch ev := r.ev.(type) {
+			case xproto.ExposeEvent:
+				if ev.Count == 0 {
+					if err := w.draw(); err != nil {
+						return render.EventNone, err
+					}
+				}
+			case xproto.ButtonPressEvent:
+				switch ev.Detail {
+				case xproto.ButtonIndex1:
+					// The layout has its origin in the bottom left corner.
+					v := pixel.V(
+						float64(ev.EventX)+0.5,
+						float64(w.height-int(ev.EventY))-0.5,
+					)
+					if !render.ClickLink(w.layout, opts, v) {
+						return render.EventLeftClick, nil
+					}
+				case xproto.ButtonIndex2:
+					if opts.Copy == nil {
+						return render.EventMiddleClick, nil
+					}
+					if err := w.copy(opts.Copy(), ev.Time); err != nil {
+						log.Printf("warning: could not copy text: %v", err)
+						continue
+					}
+					shown := w.layout
+					if err := w.update(shown.Inverted(), opts); err != nil {
+						return render.EventNone, err
+					}
+					w.layout = shown
+					restore = time.After(render.FeedbackDuration)
+				case xproto.ButtonIndex3:
+					return render.EventRightClick, nil
+				}
+			case xproto.SelectionRequestEvent:
+				if w.clipboard != nil {
+					if err := w.clipboard.handleRequest(ev); err != nil {
+						log.Printf("warning: could not paste text: %v", err)
+					}
+				}
+			case xproto.DestroyNotifyEvent:
+				return render.EventClosed, nil
+			}
+		case <-restore:
+			if err := w.update(w.layout, opts); err != nil {
+				return render.EventNone, err
+			}
+		case <-timeout:
+			return render.EventTimeout, nil
+		case <-opts.Cancel:
+			return render.EventCanceled, nil
+		case n := <-opts.Updates:
+			if err := w.update(opts.Relayout(n), opts); err != nil {
+				return render.EventNone, err
+			}
+			timeout = render.Timeout(opts.Duration)
+		}
+	}
+}
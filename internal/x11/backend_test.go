@@ -0,0 +1,167 @@
+package x11
+
+import (
+	"image/color"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LinusMB/Notify/internal/ewmh"
+	ifont "github.com/LinusMB/Notify/internal/font"
+	"github.com/LinusMB/Notify/internal/layout"
+	"github.com/LinusMB/Notify/internal/parsing"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+	"github.com/jezek/xgb/xtest"
+)
+
+// The tests below need an X server, e.g. run them with
+//
+//	xvfb-run go test ./internal/x11
+
+func connect(t *testing.T) *xgb.Conn {
+	t.Helper()
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set")
+	}
+	c, err := xgb.NewConn()
+	if err != nil {
+		t.Skipf("could not connect to X server: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func testLayout(t *testing.T) *layout.Layout {
+	t.Helper()
+	fs, err := ifont.LoadOpentypeFontSetDefault(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layout.New(layout.Config{
+		Fonts:       fs,
+		Padding:     10,
+		BorderWidth: 2,
+		BorderColor: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Background:  layout.Background{Color: color.NRGBA{B: 0xff, A: 0xff}},
+		Foreground:  color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Opacity:     1,
+	}, parsing.ParseNotification("[Title]Body"))
+}
+
+func TestWindow(t *testing.T) {
+	c := connect(t)
+	w, err := newWindow(c, testLayout(t), render.Options{
+		Title: "notify",
+		X:     10,
+		Y:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		ev, xerr := c.WaitForEvent()
+		if xerr != nil {
+			t.Fatal(xerr)
+		}
+		if _, ok := ev.(xproto.ExposeEvent); ok {
+			break
+		}
+	}
+	if err := w.draw(); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := xproto.GetImage(
+		c,
+		xproto.ImageFormatZPixmap,
+		xproto.Drawable(w.id),
+		0,
+		0,
+		uint16(w.width),
+		uint16(w.height),
+		0xffffffff,
+	).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare the color channels only, as the padding byte of 24 bit
+	// visuals is undefined.
+	mask := uint32(1)<<w.depth - 1
+	for i := 0; i+4 <= len(w.data); i += 4 {
+		got := xgb.Get32(img.Data[i:]) & mask
+		want := xgb.Get32(w.data[i:]) & mask
+		if got != want {
+			t.Fatalf("pixel %d: got %#x, want %#x", i/4, got, want)
+		}
+	}
+
+	windowType, err := ewmh.InternAtom(c, "_NET_WM_WINDOW_TYPE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notification, err := ewmh.InternAtom(c, "_NET_WM_WINDOW_TYPE_NOTIFICATION")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prop, err := xproto.GetProperty(
+		c, false, w.id, windowType, xproto.AtomAtom, 0, 1,
+	).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prop.Value) != 4 || xproto.Atom(xgb.Get32(prop.Value)) != notification {
+		t.Errorf("want window type notification, got %v", prop.Value)
+	}
+}
+
+func TestShow(t *testing.T) {
+	c := connect(t)
+	if err := xtest.Init(c); err != nil {
+		t.Skipf("XTEST is not supported: %v", err)
+	}
+	root := xproto.Setup(c).DefaultScreen(c).Root
+
+	t.Run("timeout", func(t *testing.T) {
+		ev, err := Backend{}.Show(testLayout(t), render.Options{
+			Duration: 50 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev != render.EventTimeout {
+			t.Errorf("got event %v, want %v", ev, render.EventTimeout)
+		}
+	})
+
+	t.Run("right click", func(t *testing.T) {
+		done := make(chan struct{})
+		defer close(done)
+		// Click into the window until it is mapped and receives the click.
+		go func() {
+			for {
+				xtest.FakeInput(c, xproto.MotionNotify, 0, 0, root, 20, 20, 0)
+				xtest.FakeInput(c, xproto.ButtonPress, 3, 0, root, 0, 0, 0)
+				xtest.FakeInput(c, xproto.ButtonRelease, 3, 0, root, 0, 0, 0)
+				c.Sync()
+				select {
+				case <-done:
+					return
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+		}()
+		ev, err := Backend{}.Show(testLayout(t), render.Options{
+			X:        10,
+			Y:        10,
+			Duration: 5 * time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev != render.EventRightClick {
+			t.Errorf("got event %v, want %v", ev, render.EventRightClick)
+		}
+	})
+}
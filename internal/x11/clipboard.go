@@ -0,0 +1,130 @@
+package x11
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/LinusMB/Notify/internal/ewmh"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// clipboard owns the CLIPBOARD selection for a window and hands its text
+// to the clients that paste it. The text is lost when the window closes,
+// unless a clipboard manager takes it over. It is handed over in a single
+// request instead of incrementally (INCR), which limits its size.
+type clipboard struct {
+	c         *xgb.Conn
+	win       xproto.Window
+	selection xproto.Atom
+	targets   xproto.Atom
+	utf8      xproto.Atom
+	text      string
+}
+
+func newClipboard(c *xgb.Conn, win xproto.Window) (*clipboard, error) {
+	cb := clipboard{c: c, win: win}
+	for _, a := range []struct {
+		atom *xproto.Atom
+		name string
+	}{
+		{&cb.selection, "CLIPBOARD"},
+		{&cb.targets, "TARGETS"},
+		{&cb.utf8, "UTF8_STRING"},
+	} {
+		atom, err := ewmh.InternAtom(c, a.name)
+		if err != nil {
+			return nil, err
+		}
+		*a.atom = atom
+	}
+	return &cb, nil
+}
+
+// maxText returns the size of the largest text that fits into a single
+// ChangeProperty request besides its header.
+func (cb *clipboard) maxText() int {
+	const header = 24
+	return int(xproto.Setup(cb.c).MaximumRequestLength)*4 - header
+}
+
+// set takes over the selection with text at time t, the time of the event
+// that copied it.
+func (cb *clipboard) set(text string, t xproto.Timestamp) error {
+	if max := cb.maxText(); len(text) > max {
+		return fmt.Errorf(
+			"could not own clipboard: text of %d bytes exceeds %d bytes",
+			len(text),
+			max,
+		)
+	}
+	cb.text = text
+	if err := xproto.SetSelectionOwnerChecked(
+		cb.c,
+		cb.win,
+		cb.selection,
+		t,
+	).Check(); err != nil {
+		return fmt.Errorf("could not own clipboard: %w", err)
+	}
+	return nil
+}
+
+// handleRequest converts the selection to the target of the request and
+// notifies the requestor. Errors concern only the requestor, which may e.g.
+// have been destroyed in the meantime.
+func (cb *clipboard) handleRequest(ev xproto.SelectionRequestEvent) error {
+	property := ev.Property
+	// Obsolete clients leave the property to the owner.
+	if property == xproto.AtomNone {
+		property = ev.Target
+	}
+	var (
+		typ    xproto.Atom
+		format byte
+		data   []byte
+	)
+	switch ev.Target {
+	case cb.targets:
+		typ, format = xproto.AtomAtom, 32
+		for _, a := range []xproto.Atom{cb.targets, cb.utf8, xproto.AtomString} {
+			data = binary.LittleEndian.AppendUint32(data, uint32(a))
+		}
+	case cb.utf8, xproto.AtomString:
+		typ, format, data = ev.Target, 8, []byte(cb.text)
+	default:
+		property = xproto.AtomNone
+	}
+	if ev.Selection != cb.selection {
+		property = xproto.AtomNone
+	}
+
+	if property != xproto.AtomNone {
+		if err := xproto.ChangePropertyChecked(
+			cb.c,
+			xproto.PropModeReplace,
+			ev.Requestor,
+			property,
+			typ,
+			format,
+			uint32(len(data)*8/int(format)),
+			data,
+		).Check(); err != nil {
+			return fmt.Errorf("could not hand over clipboard: %w", err)
+		}
+	}
+	notify := xproto.SelectionNotifyEvent{
+		Time:      ev.Time,
+		Requestor: ev.Requestor,
+		Selection: ev.Selection,
+		Target:    ev.Target,
+		Property:  property,
+	}
+	return xproto.SendEventChecked(
+		cb.c,
+		false,
+		ev.Requestor,
+		0,
+		string(notify.Bytes()),
+	).Check()
+}
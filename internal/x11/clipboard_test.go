@@ -0,0 +1,113 @@
+package x11
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LinusMB/Notify/internal/ewmh"
+	"github.com/LinusMB/Notify/internal/render"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+func TestClipboard(t *testing.T) {
+	c := connect(t)
+	w, err := newWindow(c, testLayout(t), render.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.copy("copied text", xproto.TimeCurrentTime); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			ev, _ := c.WaitForEvent()
+			if ev == nil {
+				return
+			}
+			if req, ok := ev.(xproto.SelectionRequestEvent); ok {
+				w.clipboard.handleRequest(req)
+			}
+		}
+	}()
+
+	// Paste from another client into a property of its window.
+	pc, err := xgb.NewConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	screen := xproto.Setup(pc).DefaultScreen(pc)
+	win, err := xproto.NewWindowId(pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xproto.CreateWindowChecked(
+		pc, 0, win, screen.Root, 0, 0, 1, 1, 0,
+		xproto.WindowClassInputOnly, screen.RootVisual, 0, nil,
+	).Check(); err != nil {
+		t.Fatal(err)
+	}
+	property, err := ewmh.InternAtom(pc, "NOTIFY_PASTE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xproto.ConvertSelectionChecked(
+		pc, win, w.clipboard.selection, w.clipboard.utf8, property, xproto.TimeCurrentTime,
+	).Check(); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		ev, xerr := pc.WaitForEvent()
+		if xerr != nil {
+			t.Fatal(xerr)
+		}
+		if n, ok := ev.(xproto.SelectionNotifyEvent); ok {
+			if n.Property != property {
+				t.Fatalf("selection was not converted, got property %d", n.Property)
+			}
+			break
+		}
+	}
+	prop, err := xproto.GetProperty(
+		pc, false, win, property, xproto.AtomAny, 0, 1024,
+	).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(prop.Value); got != "copied text" {
+		t.Errorf("got pasted text %q, want %q", got, "copied text")
+	}
+}
+
+func TestClipboard_Errors(t *testing.T) {
+	c := connect(t)
+	w, err := newWindow(c, testLayout(t), render.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.copy("copied text", xproto.TimeCurrentTime); err != nil {
+		t.Fatal(err)
+	}
+
+	tooLong := strings.Repeat("x", w.clipboard.maxText()+1)
+	if err := w.copy(tooLong, xproto.TimeCurrentTime); err == nil {
+		t.Error("want an error for text that does not fit into a request")
+	}
+	if w.clipboard.text != "copied text" {
+		t.Error("want the copied text kept")
+	}
+
+	// A requestor that is gone is an error of the request only.
+	err = w.clipboard.handleRequest(xproto.SelectionRequestEvent{
+		Time:      xproto.TimeCurrentTime,
+		Owner:     w.id,
+		Requestor: 0xdeadbeef,
+		Selection: w.clipboard.selection,
+		Target:    w.clipboard.utf8,
+		Property:  w.clipboard.utf8,
+	})
+	if err == nil {
+		t.Error("want an error for a destroyed requestor")
+	}
+}
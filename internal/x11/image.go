@@ -0,0 +1,109 @@
+package x11
+
+import (
+	"errors"
+	"image"
+	"math/bits"
+
+	"github.com/jezek/xgb/xproto"
+)
+
+// A pixelFormat describes how the server stores the pixels of a TrueColor
+// visual with 32 bits per pixel.
+type pixelFormat struct {
+	red, green, blue, alpha uint32
+	msbFirst                bool
+}
+
+type visual struct {
+	id     xproto.Visualid
+	depth  byte
+	format pixelFormat
+}
+
+// findVisual returns a TrueColor visual of the given depth that is stored
+// with 32 bits per pixel, which covers the usual 24 and 32 bit visuals.
+func findVisual(
+	setup *xproto.SetupInfo,
+	screen *xproto.ScreenInfo,
+	depth byte,
+) (visual, error) {
+	bpp32 := false
+	for _, f := range setup.PixmapFormats {
+		if f.Depth == depth && f.BitsPerPixel == 32 {
+			bpp32 = true
+		}
+	}
+	if !bpp32 {
+		return visual{}, errors.New("no 32 bits per pixel format for visual")
+	}
+	for _, d := range screen.AllowedDepths {
+		if d.Depth != depth {
+			continue
+		}
+		for _, v := range d.Visuals {
+			if v.Class != xproto.VisualClassTrueColor {
+				continue
+			}
+			format := pixelFormat{
+				red:      v.RedMask,
+				green:    v.GreenMask,
+				blue:     v.BlueMask,
+				msbFirst: setup.ImageByteOrder == xproto.ImageOrderMSBFirst,
+			}
+			if depth == 32 {
+				format.alpha = ^(v.RedMask | v.GreenMask | v.BlueMask)
+			}
+			return visual{v.VisualId, depth, format}, nil
+		}
+	}
+	return visual{}, errors.New("no TrueColor visual")
+}
+
+// scale places the 8 bit channel value v into the bits of mask.
+func scale(v uint8, mask uint32) uint32 {
+	if mask == 0 {
+		return 0
+	}
+	shift := bits.TrailingZeros32(mask)
+	width := bits.OnesCount32(mask)
+	if width >= 8 {
+		return uint32(v) << (shift + width - 8) & mask
+	}
+	return uint32(v) >> (8 - width) << shift
+}
+
+// encodeImage converts a premultiplied image to the ZPixmap data of the
+// pixel format.
+func encodeImage(img *image.RGBA, f pixelFormat) []byte {
+	b := img.Bounds()
+	data := make([]byte, 0, 4*b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			p := scale(c.R, f.red) |
+				scale(c.G, f.green) |
+				scale(c.B, f.blue) |
+				scale(c.A, f.alpha)
+			if f.msbFirst {
+				data = append(data, byte(p>>24), byte(p>>16), byte(p>>8), byte(p))
+			} else {
+				data = append(data, byte(p), byte(p>>8), byte(p>>16), byte(p>>24))
+			}
+		}
+	}
+	return data
+}
+
+// putImageHeader is the size of a PutImage request without its data.
+const putImageHeader = 24
+
+// stripRows returns how many rows of the given stride fit into a single
+// request of at most maxLength bytes.
+func stripRows(stride, maxLength int) int {
+	rows := (maxLength - putImageHeader) / stride
+	if rows < 1 {
+		return 1
+	}
+	return rows
+}
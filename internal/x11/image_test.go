@@ -0,0 +1,73 @@
+package x11
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff})
+	img.SetRGBA(1, 0, color.RGBA{R: 0x80, A: 0x80})
+
+	tests := []struct {
+		name   string
+		format pixelFormat
+		want   []byte
+	}{
+		{
+			"depth 24",
+			pixelFormat{red: 0xff0000, green: 0xff00, blue: 0xff},
+			[]byte{0x33, 0x22, 0x11, 0, 0, 0, 0x80, 0},
+		},
+		{
+			"depth 32",
+			pixelFormat{red: 0xff0000, green: 0xff00, blue: 0xff, alpha: 0xff000000},
+			[]byte{0x33, 0x22, 0x11, 0xff, 0, 0, 0x80, 0x80},
+		},
+		{
+			"depth 32 msb first",
+			pixelFormat{
+				red:      0xff0000,
+				green:    0xff00,
+				blue:     0xff,
+				alpha:    0xff000000,
+				msbFirst: true,
+			},
+			[]byte{0xff, 0x11, 0x22, 0x33, 0x80, 0x80, 0, 0},
+		},
+		{
+			"bgr 30 bit",
+			pixelFormat{red: 0x3ff, green: 0xffc00, blue: 0x3ff00000},
+			[]byte{0x44, 0x20, 0xc2, 0x0c, 0x00, 0x02, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeImage(img, tt.format)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripRows(t *testing.T) {
+	tests := []struct {
+		stride, maxLength, want int
+	}{
+		{400, 262140, 655},
+		{400, 424, 1},
+		{400, 100, 1},
+	}
+	for _, tt := range tests {
+		if got := stripRows(tt.stride, tt.maxLength); got != tt.want {
+			t.Errorf(
+				"stripRows(%d, %d) = %d, want %d",
+				tt.stride, tt.maxLength, got, tt.want,
+			)
+		}
+	}
+}